@@ -0,0 +1,42 @@
+package signal
+
+import (
+	"context"
+	"os"
+	"sync"
+)
+
+// receivedSignalKey is the context key of the signal received by the context of StartListenContext.
+type receivedSignalKey struct{}
+
+// receivedSignal is set once the termination signal is received.
+type receivedSignal struct {
+	lock sync.Mutex
+	sig  os.Signal
+}
+
+func (r *receivedSignal) set(sig os.Signal) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if r.sig == nil {
+		r.sig = sig
+	}
+}
+
+func (r *receivedSignal) get() os.Signal {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.sig
+}
+
+// FromContext returns the termination signal which cancelled ctx, ctx has to be derived from the one returned by
+// StartListenContext, which includes the context given to termination procedures.
+// It returns false if no termination signal is received yet.
+func FromContext(ctx context.Context) (os.Signal, bool) {
+	received, ok := ctx.Value(receivedSignalKey{}).(*receivedSignal)
+	if !ok {
+		return nil, false
+	}
+	sig := received.get()
+	return sig, sig != nil
+}
@@ -0,0 +1,41 @@
+package signal
+
+import (
+	"context"
+	"os"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFromContextReturnsReceivedTerminationSignal(t *testing.T) {
+	t.Parallel()
+	source := make(chan os.Signal)
+	exited := make(chan int, 1)
+	handlers := newHandlers(WithSignalSource(source), WithLogger(nopTestLogger{}))
+	handlers.SetExit(func(code int) { exited <- code })
+	listenCtx, stop := handlers.StartListenContext(context.Background())
+	defer stop()
+
+	_, ok := FromContext(listenCtx)
+	assert.False(t, ok)
+	var inProcedure os.Signal
+	handlers.RegisterTerminationProcedureCtx(func(ctx context.Context, _ os.Signal) error {
+		inProcedure, _ = FromContext(ctx)
+		return nil
+	}, "")
+
+	source <- syscall.SIGINT
+	assert.Equal(t, 0, <-exited)
+	<-listenCtx.Done()
+	sig, ok := FromContext(listenCtx)
+	assert.True(t, ok)
+	assert.Equal(t, syscall.SIGINT, sig)
+	assert.Equal(t, syscall.SIGINT, inProcedure)
+}
+
+func TestFromContextWithoutListenContext(t *testing.T) {
+	_, ok := FromContext(context.Background())
+	assert.False(t, ok)
+}
@@ -0,0 +1,110 @@
+package signal
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// DrainTracker counts in-flight work, e.g. HTTP requests, so termination can wait for them to drain.
+type DrainTracker struct {
+	lock  sync.Mutex
+	count int
+	// zero is closed once count drops to zero.
+	zero chan struct{}
+}
+
+// NewDrainTracker creates a DrainTracker with nothing in flight.
+func NewDrainTracker() *DrainTracker {
+	return &DrainTracker{}
+}
+
+// Add marks one more piece of work in flight.
+func (d *DrainTracker) Add() {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	if d.count == 0 {
+		d.zero = make(chan struct{})
+	}
+	d.count++
+}
+
+// Done marks one piece of work finished, it panics if nothing is in flight.
+func (d *DrainTracker) Done() {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	if d.count == 0 {
+		panic("signal: DrainTracker.Done called without matching Add")
+	}
+	d.count--
+	if d.count == 0 {
+		close(d.zero)
+	}
+}
+
+// Count returns the number of in-flight work.
+func (d *DrainTracker) Count() int {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	return d.count
+}
+
+// Procedure returns a TerminationFunc waiting until nothing is in flight, it fails if not drained within timeout.
+// Zero timeout means no limit.
+func (d *DrainTracker) Procedure(timeout time.Duration) TerminationFunc {
+	return func(os.Signal) error {
+		zero := d.drained()
+		if timeout <= 0 {
+			<-zero
+			return nil
+		}
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		select {
+		case <-zero:
+			return nil
+		case <-timer.C:
+			return fmt.Errorf("not drained within %s, %d still in flight", timeout, d.Count())
+		}
+	}
+}
+
+// drained returns a channel which is closed once nothing is in flight.
+func (d *DrainTracker) drained() <-chan struct{} {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	if d.count == 0 {
+		zero := make(chan struct{})
+		close(zero)
+		return zero
+	}
+	return d.zero
+}
+
+// TrackInFlight marks one piece of work in flight until done is called, termination procedures only run once no
+// tracked work is in flight or the shutdown timeout or deadline is reached. Calling done more than once has no effect.
+func (s *Handlers) TrackInFlight() (done func()) {
+	s.tracked.Add()
+	var once sync.Once
+	return func() {
+		once.Do(s.tracked.Done)
+	}
+}
+
+// waitTracked waits until no work tracked by TrackInFlight is in flight or ctx is done.
+func (s *Handlers) waitTracked(ctx context.Context) {
+	drained := s.tracked.drained()
+	select {
+	case <-drained:
+		return
+	default:
+	}
+	s.log.Info("waiting for in-flight work: ", s.tracked.Count())
+	select {
+	case <-drained:
+	case <-ctx.Done():
+		logWarn(s.log, "in-flight work not done before shutdown timeout or deadline: ", s.tracked.Count())
+	}
+}
@@ -0,0 +1,86 @@
+package signal
+
+import (
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/flexi-cache/pkg/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDrainTrackerProcedureWaitsUntilDrained(t *testing.T) {
+	t.Parallel()
+	tracker := NewDrainTracker()
+	tracker.Add()
+	tracker.Add()
+	go func() {
+		time.Sleep(time.Millisecond * 10)
+		tracker.Done()
+		tracker.Done()
+	}()
+
+	handlers := _newHandlers(nil)
+	handlers.RegisterTerminationProcedure(tracker.Procedure(time.Second), "draining requests")
+	report := handlers.runTerminationProcedures(syscall.SIGTERM)
+	assert.Equal(t, 0, report.ExitCode)
+	assert.Equal(t, 0, tracker.Count())
+}
+
+func TestDrainTrackerProcedureTimesOut(t *testing.T) {
+	t.Parallel()
+	tracker := NewDrainTracker()
+	tracker.Add()
+	err := tracker.Procedure(time.Millisecond * 10)(syscall.SIGTERM)
+	assert.EqualError(t, err, "not drained within 10ms, 1 still in flight")
+}
+
+func TestDrainTrackerProcedureReturnsAtOnceWhenIdle(t *testing.T) {
+	t.Parallel()
+	tracker := NewDrainTracker()
+	tracker.Add()
+	tracker.Done()
+	assert.NoError(t, tracker.Procedure(0)(syscall.SIGTERM))
+	assert.Panics(t, tracker.Done)
+}
+
+func TestHandlersWaitsForTrackedWorkBeforeProcedures(t *testing.T) {
+	t.Parallel()
+	handlers := _newHandlers(nil)
+	handlers.SetLogger(nopTestLogger{})
+	var lock sync.Mutex
+	order := ""
+	record := func(step string) {
+		lock.Lock()
+		defer lock.Unlock()
+		order += step
+	}
+	handlers.RegisterTerminationProcedure(NewTerminationFunc(func() { record("procedure ") }), "")
+
+	done := handlers.TrackInFlight()
+	go func() {
+		time.Sleep(time.Millisecond * 20)
+		record("work ")
+		done()
+		done()
+	}()
+	handlers.handleSignal(syscall.SIGTERM)
+	assert.Equal(t, "work procedure ", order)
+}
+
+func TestHandlersStopsWaitingForTrackedWorkOnShutdownTimeout(t *testing.T) {
+	t.Parallel()
+	handlers := newHandlers(WithLogger(nopTestLogger{}), WithShutdownTimeout(time.Millisecond*20))
+	handlers.SetExit(func(int) {})
+	handlers.TrackInFlight()
+	testutil.AssertReturnsWithin(t, time.Second, func() { handlers.handleSignal(syscall.SIGTERM) })
+}
+
+func TestHandlersStopsWaitingForTrackedWorkOnShutdownDeadline(t *testing.T) {
+	t.Parallel()
+	handlers := newHandlers(WithLogger(nopTestLogger{}), WithShutdownDeadline(time.Millisecond*20))
+	handlers.SetExit(func(int) {})
+	handlers.TrackInFlight()
+	testutil.AssertReturnsWithin(t, time.Second, func() { handlers.handleSignal(syscall.SIGTERM) })
+}
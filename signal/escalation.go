@@ -0,0 +1,104 @@
+package signal
+
+import (
+	"context"
+	"time"
+)
+
+// Stages of the soft then hard escalation, see SetEscalation.
+const (
+	StageSoft = "soft"
+	StageHard = "hard"
+)
+
+// SetEscalation enables the soft then hard escalation of termination procedures, zero softBudget disables it.
+//
+// Procedures in StageSoft (e.g. stop accepting, drain) run first within softBudget. Procedures in StageHard
+// (e.g. force-close connections) only run if the soft ones are not done within softBudget, they run within hardBudget
+// after all soft ones, zero hardBudget means no limit. Procedures in other stages are not affected.
+func (s *Handlers) SetEscalation(softBudget, hardBudget time.Duration) {
+	s.globalLock.Lock()
+	defer s.globalLock.Unlock()
+	s.softBudget, s.hardBudget = softBudget, hardBudget
+}
+
+// escalationRun tracks the soft and hard phases during a single run of termination procedures.
+type escalationRun struct {
+	budgets   map[string]time.Duration
+	contexts  map[string]context.Context
+	cancels   []context.CancelFunc
+	expired   map[string]bool
+	escalated bool
+}
+
+func (s *Handlers) newEscalationRun() *escalationRun {
+	s.globalLock.RLock()
+	defer s.globalLock.RUnlock()
+	if s.softBudget <= 0 {
+		return nil
+	}
+	return &escalationRun{
+		budgets:  map[string]time.Duration{StageSoft: s.softBudget, StageHard: s.hardBudget},
+		contexts: make(map[string]context.Context),
+		expired:  make(map[string]bool),
+	}
+}
+
+// order moves hard procedures after all others.
+func (e *escalationRun) order(procs []terminationProcedure) []terminationProcedure {
+	if e == nil {
+		return procs
+	}
+	ordered := make([]terminationProcedure, 0, len(procs))
+	var hard []terminationProcedure
+	for _, proc := range procs {
+		if proc.stage == StageHard {
+			hard = append(hard, proc)
+		} else {
+			ordered = append(ordered, proc)
+		}
+	}
+	return append(ordered, hard...)
+}
+
+// skip reports whether procedures of stage must not run.
+func (e *escalationRun) skip(stage string) bool {
+	if e == nil {
+		return false
+	}
+	return e.expired[stage] || (stage == StageHard && !e.escalated)
+}
+
+// context returns the context bound by the budget of stage, the budget starts when first asked.
+func (e *escalationRun) context(parent context.Context, stage string) context.Context {
+	if e == nil {
+		return parent
+	}
+	budget, exists := e.budgets[stage]
+	if !exists || budget <= 0 {
+		return parent
+	}
+	if ctx, exists := e.contexts[stage]; exists {
+		return ctx
+	}
+	ctx, cancel := context.WithTimeout(parent, budget)
+	e.contexts[stage], e.cancels = ctx, append(e.cancels, cancel)
+	return ctx
+}
+
+// expire marks the budget of stage elapsed, hard procedures are unlocked once the soft budget elapsed.
+func (e *escalationRun) expire(stage string) {
+	e.expired[stage] = true
+	if stage == StageSoft {
+		e.escalated = true
+	}
+}
+
+func (e *escalationRun) stop() {
+	if e == nil {
+		return
+	}
+	for _, cancel := range e.cancels {
+		cancel()
+	}
+}
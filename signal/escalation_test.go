@@ -0,0 +1,74 @@
+package signal
+
+import (
+	"os"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandlersEscalatesToHardWhenSoftBudgetElapses(t *testing.T) {
+	t.Parallel()
+	handlers := _newHandlers(nil)
+	handlers.SetEscalation(time.Millisecond*20, time.Second)
+	block := make(chan struct{})
+	defer close(block)
+	// the soft procedure is abandoned while still running, so the order is recorded under a lock
+	var lock sync.Mutex
+	order := ""
+	record := func(step string) {
+		lock.Lock()
+		defer lock.Unlock()
+		order += step
+	}
+
+	handlers.RegisterTerminationProcedureInStage(NewTerminationFunc(func() { record("force-close ") }), "", StageHard)
+	handlers.RegisterTerminationProcedureInStage(func(os.Signal) error {
+		record("drain ")
+		<-block
+		return nil
+	}, "", StageSoft)
+	handlers.RegisterTerminationProcedureInStage(NewTerminationFunc(func() { record("never ") }), "", StageSoft)
+	handlers.RegisterTerminationProcedure(NewTerminationFunc(func() { record("flush ") }), "")
+
+	report := handlers.runTerminationProcedures(syscall.SIGTERM)
+	lock.Lock()
+	assert.Equal(t, "drain flush force-close ", order)
+	lock.Unlock()
+	var statuses []ProcedureStatus
+	for _, result := range report.Procedures {
+		statuses = append(statuses, result.Status)
+	}
+	assert.Equal(t, []ProcedureStatus{TimedOut, Skipped, Succeeded, Succeeded}, statuses)
+	assert.Equal(t, "", report.TimedOutStage)
+	assert.Equal(t, 1, report.ExitCode)
+}
+
+func TestHandlersSkipsHardWhenSoftDoneInTime(t *testing.T) {
+	t.Parallel()
+	handlers := _newHandlers(nil)
+	handlers.SetEscalation(time.Second, time.Second)
+	order := ""
+
+	handlers.RegisterTerminationProcedureInStage(NewTerminationFunc(func() { order += "drain " }), "", StageSoft)
+	handlers.RegisterTerminationProcedureInStage(NewTerminationFunc(func() { order += "force-close " }), "", StageHard)
+
+	report := handlers.runTerminationProcedures(syscall.SIGTERM)
+	assert.Equal(t, "drain ", order)
+	assert.Equal(t, Skipped, report.Procedures[1].Status)
+	assert.Equal(t, 0, report.ExitCode)
+}
+
+func TestHandlersIgnoresEscalationStagesByDefault(t *testing.T) {
+	t.Parallel()
+	handlers := _newHandlers(nil)
+	order := ""
+	handlers.RegisterTerminationProcedureInStage(NewTerminationFunc(func() { order += "force-close " }), "", StageHard)
+	handlers.RegisterTerminationProcedureInStage(NewTerminationFunc(func() { order += "drain " }), "", StageSoft)
+
+	handlers.runTerminationProcedures(syscall.SIGTERM)
+	assert.Equal(t, "force-close drain ", order)
+}
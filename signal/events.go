@@ -0,0 +1,147 @@
+package signal
+
+import (
+	"os"
+	"time"
+)
+
+// EventKind describes what an Event is about.
+type EventKind int
+
+const (
+	// EventSignalReceived means a signal is about to be dispatched to handlers.
+	EventSignalReceived EventKind = iota
+	// EventShutdownStarted means termination procedures are about to run.
+	EventShutdownStarted
+	// EventProcedureDone means a termination procedure ended or has been skipped.
+	EventProcedureDone
+	// EventShutdownDone means all termination procedures are done.
+	EventShutdownDone
+)
+
+func (k EventKind) String() string {
+	switch k {
+	case EventSignalReceived:
+		return "signal received"
+	case EventShutdownStarted:
+		return "shutdown started"
+	case EventProcedureDone:
+		return "procedure done"
+	case EventShutdownDone:
+		return "shutdown done"
+	}
+	return "unknown"
+}
+
+// Event is a lifecycle event of Handlers, see Events.
+type Event struct {
+	Kind   EventKind
+	Signal os.Signal
+	// Procedure is the result of the termination procedure, only set for EventProcedureDone.
+	Procedure ProcedureResult
+}
+
+const (
+	// eventsBufferSize is the capacity of the channel returned by Events.
+	eventsBufferSize = 64
+	// eventsDrainTimeout bounds how long Close waits for buffered events to be read.
+	eventsDrainTimeout = time.Millisecond * 100
+)
+
+// Events returns the channel receiving lifecycle events, it is created on the first call and closed by Close.
+// NOTE: events are dropped instead of blocking when the channel is full, so a slow consumer never delays shutdown.
+func (s *Handlers) Events() <-chan Event {
+	s.eventsLock.Lock()
+	defer s.eventsLock.Unlock()
+	s.observed.Store(true)
+	if s.events == nil {
+		s.events = make(chan Event, eventsBufferSize)
+		if s.eventsClosed {
+			close(s.events)
+		}
+	}
+	return s.events
+}
+
+// Notify returns a new channel receiving every dispatched signal, ignored signals excluded, buffering up to buffer
+// signals. Each call subscribes a channel of its own, all of them are closed by Close.
+// NOTE: signals are dropped instead of blocking when the channel is full, so a slow consumer never delays dispatch.
+func (s *Handlers) Notify(buffer int) <-chan os.Signal {
+	if buffer < 0 {
+		buffer = 0
+	}
+	c := make(chan os.Signal, buffer)
+	s.eventsLock.Lock()
+	defer s.eventsLock.Unlock()
+	if s.eventsClosed {
+		close(c)
+		return c
+	}
+	s.observed.Store(true)
+	s.subscribers = append(s.subscribers, c)
+	return c
+}
+
+// publish sends sig to the channels returned by Notify without blocking.
+func (s *Handlers) publish(sig os.Signal) {
+	if !s.observed.Load() {
+		return
+	}
+	s.eventsLock.Lock()
+	defer s.eventsLock.Unlock()
+	for _, c := range s.subscribers {
+		select {
+		case c <- sig:
+		default:
+			s.log.Debug("notify channel is full, signal is dropped: ", sig)
+		}
+	}
+}
+
+// Close stops sending events and closes the channel returned by Events and the channels returned by Notify.
+// Events already buffered are left for the consumer for a short while before the channel is closed,
+// those still not read by then are dropped. Close is safe to call more than once.
+func (s *Handlers) Close() error {
+	s.eventsLock.Lock()
+	events, closed, subscribers := s.events, s.eventsClosed, s.subscribers
+	s.eventsClosed, s.subscribers = true, nil
+	s.eventsLock.Unlock()
+	for _, c := range subscribers {
+		close(c)
+	}
+	if closed || events == nil {
+		return nil
+	}
+
+	deadline := time.Now().Add(eventsDrainTimeout)
+	for len(events) > 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	dropped := 0
+	for len(events) > 0 {
+		<-events
+		dropped++
+	}
+	if dropped > 0 {
+		s.log.Info("events not read before close are dropped: ", dropped)
+	}
+	close(events)
+	return nil
+}
+
+// emit sends e to the events channel, if any, without blocking.
+func (s *Handlers) emit(e Event) {
+	if !s.observed.Load() {
+		return
+	}
+	s.eventsLock.Lock()
+	defer s.eventsLock.Unlock()
+	if s.events == nil || s.eventsClosed {
+		return
+	}
+	select {
+	case s.events <- e:
+	default:
+		s.log.Debug("events channel is full, event is dropped: ", e.Kind)
+	}
+}
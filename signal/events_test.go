@@ -0,0 +1,72 @@
+package signal
+
+import (
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/flexi-cache/pkg/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandlersCloseDeliversBufferedEventsBeforeClosing(t *testing.T) {
+	t.Parallel()
+	handlers := _newHandlers(func(int) {})
+	handlers.SetLogger(nopTestLogger{})
+	handlers.RegisterTerminationProcedure(func(os.Signal) error { return nil }, "flush")
+	events := handlers.Events()
+	handlers.handleSignal(syscall.SIGTERM)
+
+	received := make(chan []EventKind)
+	go func() {
+		var kinds []EventKind
+		for e := range events {
+			time.Sleep(time.Millisecond * 5)
+			kinds = append(kinds, e.Kind)
+		}
+		received <- kinds
+	}()
+	assert.NoError(t, handlers.Close())
+	assert.Equal(t, []EventKind{EventSignalReceived, EventShutdownStarted, EventProcedureDone, EventShutdownDone}, <-received)
+}
+
+func TestHandlersCloseDropsEventsNotRead(t *testing.T) {
+	t.Parallel()
+	handlers := _newHandlers(func(int) {})
+	handlers.SetLogger(nopTestLogger{})
+	events := handlers.Events()
+	handlers.handleSignal(syscall.SIGUSR1)
+
+	testutil.AssertReturnsWithin(t, eventsDrainTimeout*5, func() {
+		assert.NoError(t, handlers.Close())
+	})
+	_, ok := <-events
+	assert.False(t, ok)
+	assert.NoError(t, handlers.Close())
+	handlers.handleSignal(syscall.SIGUSR1)
+	_, ok = <-handlers.Events()
+	assert.False(t, ok)
+}
+
+func TestHandlersNotifySendsDispatchedSignals(t *testing.T) {
+	t.Parallel()
+	handlers := _newHandlers(nil)
+	handlers.SetLogger(nopTestLogger{})
+	handlers.Ignore(syscall.SIGUSR2)
+	first, second := handlers.Notify(1), handlers.Notify(2)
+
+	handlers.Dispatch(syscall.SIGUSR2)
+	handlers.Dispatch(syscall.SIGUSR1)
+	handlers.Dispatch(syscall.SIGHUP)
+	assert.Equal(t, syscall.SIGUSR1, <-first)
+	assert.Len(t, first, 0)
+	assert.Equal(t, syscall.SIGUSR1, <-second)
+	assert.Equal(t, syscall.SIGHUP, <-second)
+
+	assert.NoError(t, handlers.Close())
+	_, open := <-first
+	assert.False(t, open)
+	_, open = <-handlers.Notify(1)
+	assert.False(t, open)
+}
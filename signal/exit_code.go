@@ -0,0 +1,68 @@
+package signal
+
+import "fmt"
+
+// ExitCodeStrategy selects the exit code from the errors of termination procedures.
+type ExitCodeStrategy int
+
+const (
+	// FirstError selects the code of the first failed procedure, it is the default.
+	FirstError ExitCodeStrategy = iota
+	// LastError selects the code of the last failed procedure.
+	LastError
+	// MaxCode selects the greatest code among failed procedures.
+	MaxCode
+)
+
+func (e ExitCodeStrategy) String() string {
+	switch e {
+	case FirstError:
+		return "first error"
+	case LastError:
+		return "last error"
+	case MaxCode:
+		return "max code"
+	}
+	return "unknown"
+}
+
+// SetExitCodeStrategy sets how the exit code is selected when several termination procedures fail.
+func (s *Handlers) SetExitCodeStrategy(strategy ExitCodeStrategy) {
+	s.globalLock.Lock()
+	defer s.globalLock.Unlock()
+	s.exitCodeStrategy = strategy
+}
+
+// SetDefaultErrorCode sets the exit code of a failed termination procedure whose error has no code wrapped by
+// WrapErrorWithCode, 1 by default. It returns an error and keeps the current code if code is out of 0-255.
+func (s *Handlers) SetDefaultErrorCode(code int) error {
+	if code < 0 || code > maxErrorCode {
+		return fmt.Errorf("default error code must be within 0-255: %d", code)
+	}
+	s.globalLock.Lock()
+	defer s.globalLock.Unlock()
+	s.defaultErrorCode = code
+	return nil
+}
+
+// SetExitCodeExtractor sets fn to get the exit code from the error of a failed termination procedure, e.g. from an
+// error type of another package. The code wrapped by WrapErrorWithCode or the default error code is used if fn
+// returns false.
+func (s *Handlers) SetExitCodeExtractor(fn func(error) (int, bool)) {
+	s.globalLock.Lock()
+	defer s.globalLock.Unlock()
+	s.exitCodeExtractor = fn
+}
+
+// fold returns the exit code selected from the current one and the code of another failed procedure.
+func (e ExitCodeStrategy) fold(current, code int) int {
+	switch {
+	case current == 0:
+		return code
+	case e == LastError:
+		return code
+	case e == MaxCode && code > current:
+		return code
+	}
+	return current
+}
@@ -0,0 +1,78 @@
+package signal
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandlersExitCodeStrategies(t *testing.T) {
+	for strategy, expected := range map[ExitCodeStrategy]int{FirstError: 3, LastError: 4, MaxCode: 7} {
+		strategy, expected := strategy, expected
+		t.Run(strategy.String(), func(t *testing.T) {
+			t.Parallel()
+			var code int
+			handlers := _newHandlers(func(c int) { code = c })
+			handlers.SetLogger(nopTestLogger{})
+			handlers.SetExitCodeStrategy(strategy)
+			for _, err := range []error{nil, WrapErrorWithCode(io.EOF, 3), WrapErrorWithCode(io.EOF, 7), nil, WrapErrorWithCode(io.EOF, 4)} {
+				err := err
+				handlers.RegisterTerminationProcedure(func(os.Signal) error { return err }, "")
+			}
+			handlers.handleSignal(syscall.SIGTERM)
+			assert.Equal(t, expected, code)
+		})
+	}
+}
+
+func TestExitCodeStrategyString(t *testing.T) {
+	assert.Equal(t, "unknown", ExitCodeStrategy(-1).String())
+}
+
+func TestHandlersUsesDefaultErrorCodeForUnwrappedErrors(t *testing.T) {
+	t.Parallel()
+	ret := -1
+	handlers := _newHandlers(func(code int) { ret = code })
+	handlers.SetLogger(nopTestLogger{})
+	assert.Error(t, handlers.SetDefaultErrorCode(256))
+	assert.NoError(t, handlers.SetDefaultErrorCode(70))
+	handlers.RegisterTerminationProcedure(func(os.Signal) error { return io.EOF }, "")
+
+	handlers.handleSignal(syscall.SIGTERM)
+	assert.Equal(t, 70, ret)
+}
+
+type statusError struct {
+	status int
+}
+
+func (e statusError) Error() string {
+	return "status " + strconv.Itoa(e.status)
+}
+
+func TestHandlersUsesExitCodeExtractor(t *testing.T) {
+	t.Parallel()
+	ret := -1
+	handlers := _newHandlers(func(code int) { ret = code })
+	handlers.SetLogger(nopTestLogger{})
+	handlers.SetExitCodeExtractor(func(err error) (int, bool) {
+		var se statusError
+		if errors.As(err, &se) {
+			return se.status, true
+		}
+		return 0, false
+	})
+	handlers.RegisterTerminationProcedure(func(os.Signal) error { return fmt.Errorf("flush: %w", statusError{17}) }, "")
+	handlers.handleSignal(syscall.SIGTERM)
+	assert.Equal(t, 17, ret)
+
+	handlers.ReplaceTerminationProcedures([]NamedProcedure{{Fn: func(os.Signal) error { return WrapErrorWithCode(io.EOF, 3) }}})
+	handlers.handleSignal(syscall.SIGTERM)
+	assert.Equal(t, 3, ret)
+}
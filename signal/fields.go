@@ -0,0 +1,84 @@
+package signal
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// FieldLogger is a Logger able to carry fields, e.g. a request or deploy ID, see Handlers.AddFields.
+type FieldLogger interface {
+	Logger
+	// WithFields returns a logger tagging every line with fields in addition to the ones of the receiver.
+	WithFields(fields map[string]interface{}) Logger
+}
+
+// AddFields changes the logger of s so every line logged by s is tagged with fields, then returns s to chain calls.
+// Loggers implementing FieldLogger carry the fields themselves, other loggers get them prepended to each line as
+// key=value sorted by key.
+// NOTE: s itself is changed, not a copy, so every user of s gets the fields. They are added to the ones set before,
+// and dropped by SetLogger.
+func (s *Handlers) AddFields(fields map[string]interface{}) *Handlers {
+	s.globalLock.Lock()
+	defer s.globalLock.Unlock()
+	if fl, ok := s.log.(FieldLogger); ok {
+		s.log = fl.WithFields(fields)
+		return s
+	}
+	s.log = newFieldsLogger(s.log, fields)
+	return s
+}
+
+// fieldsLogger prepends fields to the lines of a logger which doesn't implement FieldLogger.
+type fieldsLogger struct {
+	l      Logger
+	prefix string
+}
+
+func newFieldsLogger(l Logger, fields map[string]interface{}) fieldsLogger {
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var prefix strings.Builder
+	for _, key := range keys {
+		fmt.Fprintf(&prefix, "%s=%v ", key, fields[key])
+	}
+	return fieldsLogger{l: l, prefix: prefix.String()}
+}
+
+func (f fieldsLogger) line(args []interface{}) string {
+	return f.prefix + fmt.Sprint(args...)
+}
+
+func (f fieldsLogger) Debug(args ...interface{}) {
+	f.l.Debug(f.line(args))
+}
+
+func (f fieldsLogger) Info(args ...interface{}) {
+	f.l.Info(f.line(args))
+}
+
+func (f fieldsLogger) Warn(args ...interface{}) {
+	logWarn(f.l, f.line(args))
+}
+
+func (f fieldsLogger) Error(args ...interface{}) {
+	logError(f.l, f.line(args))
+}
+
+// WithFields keeps prepending fields, the new ones after the current ones.
+func (f fieldsLogger) WithFields(fields map[string]interface{}) Logger {
+	child := newFieldsLogger(f.l, fields)
+	child.prefix = f.prefix + child.prefix
+	return child
+}
+
+// Flush flushes the underlying logger if it buffers its output.
+func (f fieldsLogger) Flush() error {
+	if fl, ok := f.l.(flusher); ok {
+		return fl.Flush()
+	}
+	return nil
+}
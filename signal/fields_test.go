@@ -0,0 +1,45 @@
+package signal
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"os"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandlersAddFieldsPrependsFields(t *testing.T) {
+	t.Parallel()
+	logger := &recordingLogger{}
+	handlers := _newHandlers(nil)
+	handlers.SetLogger(logger)
+	chained := handlers.AddFields(map[string]interface{}{"deploy": 42, "app": "cache"}).
+		AddFields(map[string]interface{}{"region": "eu"})
+	assert.Same(t, handlers, chained)
+	handlers.RegisterTerminationProcedure(func(os.Signal) error { return io.EOF }, "flush")
+	handlers.handleSignal(syscall.SIGTERM)
+
+	assert.True(t, logger.contains("info: app=cache deploy=42 region=eu flush"))
+	assert.True(t, logger.contains("error: app=cache deploy=42 region=eu error while running termination procedure: flush, EOF"))
+}
+
+func TestHandlersAddFieldsUsesFieldLogger(t *testing.T) {
+	t.Parallel()
+	var buf bytes.Buffer
+	handlers := _newHandlers(nil)
+	handlers.SetLogger(NewSlogLogger(slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{
+		ReplaceAttr: func(_ []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			return a
+		},
+	}))))
+	handlers.AddFields(map[string]interface{}{"deploy": 42})
+	handlers.log.Info("bye")
+
+	assert.Equal(t, "level=INFO msg=bye deploy=42\n", buf.String())
+}
@@ -0,0 +1,160 @@
+package signal
+
+import (
+	"os"
+	"time"
+)
+
+// DefaultForceExitCode is the exit code used when the process is forced to exit unless SetForceExitCode is called.
+const DefaultForceExitCode = 1
+
+// SetForceExitOnSecondSignal sets whether a termination signal received while termination procedures are still
+// running exits immediately, without waiting for the procedures. It is off by default, such a signal is dropped then.
+// NOTE: when it is on, termination procedures run in their own goroutine so further signals can be received.
+func (s *Handlers) SetForceExitOnSecondSignal(enabled bool) {
+	s.terminationLock.Lock()
+	defer s.terminationLock.Unlock()
+	s.forceExit = enabled
+}
+
+// SetForceExitCode sets the exit code used when a second termination signal or the shutdown deadline forces
+// the process to exit, DefaultForceExitCode by default.
+func (s *Handlers) SetForceExitCode(code int) {
+	s.terminationLock.Lock()
+	defer s.terminationLock.Unlock()
+	s.forceExitCode = code
+}
+
+// SetForceExitWindow bounds how long after the first termination signal a second one forces exit,
+// a later one starts a fresh termination instead, once the current one ends. Zero means no bound.
+// The window defaults to the shutdown timeout, see SetShutdownTimeout.
+func (s *Handlers) SetForceExitWindow(d time.Duration) {
+	s.terminationLock.Lock()
+	defer s.terminationLock.Unlock()
+	s.forceExitWindow, s.forceExitWindowSet = d, true
+}
+
+// SetTerminationDebounce makes termination signals received within d after the one starting a termination ignored,
+// e.g. SIGTERM delivered several times in a row. Later ones are handled again. Zero disables it, which is the default.
+// NOTE: debounced signals never force exit, see SetForceExitOnSecondSignal.
+func (s *Handlers) SetTerminationDebounce(d time.Duration) {
+	s.terminationLock.Lock()
+	defer s.terminationLock.Unlock()
+	s.terminationDebounce = d
+}
+
+// handleTerminationSignals is the handler of termination signals.
+func (s *Handlers) handleTerminationSignals(sig os.Signal) {
+	s.terminationLock.Lock()
+	now := time.Now()
+	if s.terminationDebounce > 0 && !s.lastTermination.IsZero() && now.Sub(s.lastTermination) < s.terminationDebounce {
+		s.terminationLock.Unlock()
+		logWarn(s.log, "termination signal debounced: ", sig)
+		return
+	}
+	s.lastTermination = now
+	if !s.forceExit {
+		s.terminationLock.Unlock()
+		s.terminate(sig, 0)
+		return
+	}
+
+	if s.terminating && s.withinForceExitWindow(now) {
+		code := s.forceExitCode
+		s.terminationLock.Unlock()
+		s.log.Info("termination signal received again, force exiting: ", sig)
+		s.getExit()(code)
+		return
+	}
+	previous := s.terminationDone
+	if s.terminating {
+		s.log.Info("termination signal received after the force exit window, "+
+			"starting a fresh termination once the current one ends: ", sig)
+	}
+	done := make(chan struct{})
+	s.terminating, s.terminationDone, s.terminationStart = true, done, now
+	s.terminationLock.Unlock()
+
+	go func() {
+		if previous != nil {
+			<-previous
+		}
+		defer s.endTermination(done)
+		s.runTermination(sig, 0)
+	}()
+}
+
+// beginTermination marks a termination in progress for sig, ok is false if one is already in progress.
+// done must be given to endTermination once it ends.
+func (s *Handlers) beginTermination(sig os.Signal) (done chan struct{}, ok bool) {
+	s.terminationLock.Lock()
+	if s.terminating {
+		s.terminationLock.Unlock()
+		logWarn(s.log, "termination already in progress, signal dropped: ", sig)
+		return nil, false
+	}
+	done = make(chan struct{})
+	s.terminating, s.terminationDone, s.terminationStart = true, done, time.Now()
+	s.terminationLock.Unlock()
+	return done, true
+}
+
+// endTermination ends the termination started with done, the next termination signal starts a termination then
+// unless a fresh one has been started meanwhile.
+func (s *Handlers) endTermination(done chan struct{}) {
+	s.terminationLock.Lock()
+	close(done)
+	if s.terminationDone == done {
+		s.terminating, s.terminationDone = false, nil
+	}
+	s.terminationLock.Unlock()
+}
+
+// waitTerminationEnded blocks until the termination in progress, if any, has ended.
+func (s *Handlers) waitTerminationEnded() {
+	s.terminationLock.Lock()
+	done := s.terminationDone
+	s.terminationLock.Unlock()
+	if done != nil {
+		<-done
+	}
+}
+
+// withinForceExitWindow must be called with terminationLock held.
+func (s *Handlers) withinForceExitWindow(now time.Time) bool {
+	window := s.forceExitWindow
+	if !s.forceExitWindowSet {
+		s.globalLock.RLock()
+		window = s.shutdownTimeout
+		s.globalLock.RUnlock()
+	}
+	return window <= 0 || now.Sub(s.terminationStart) <= window
+}
+
+// SetShutdownDeadline sets the hard limit of a termination, the process is forced to exit with the force exit code
+// once termination procedures run longer, without waiting for the remaining ones. Zero means no limit,
+// which is the default.
+// The context given to termination procedures is done by the deadline too, if it comes before the shutdown timeout.
+// NOTE: unlike SetShutdownTimeout, the deadline does not rely on termination procedures returning.
+func (s *Handlers) SetShutdownDeadline(d time.Duration) {
+	s.terminationLock.Lock()
+	defer s.terminationLock.Unlock()
+	s.shutdownDeadline = d
+}
+
+// armShutdownDeadline starts the timer of the shutdown deadline, the returned function stops it.
+func (s *Handlers) armShutdownDeadline() (stop func()) {
+	s.terminationLock.Lock()
+	deadline, code := s.shutdownDeadline, s.forceExitCode
+	s.terminationLock.Unlock()
+	if deadline <= 0 {
+		return func() {}
+	}
+
+	timer := time.AfterFunc(deadline, func() {
+		inFlight, _ := s.inFlight.Load().(string)
+		logWarn(s.log, "shutdown deadline exceeded while running termination procedure: ", inFlight, ", force exiting")
+		s.getExit()(code)
+	})
+	return func() { timer.Stop() }
+}
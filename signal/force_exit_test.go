@@ -0,0 +1,227 @@
+package signal
+
+import (
+	"context"
+	"os"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newForceExitTest(t *testing.T) (*Handlers, chan int, chan struct{}, *atomic.Int32) {
+	codes := make(chan int, 4)
+	handlers := _newHandlers(func(code int) { codes <- code })
+	handlers.SetLogger(nopTestLogger{})
+	handlers.SetForceExitOnSecondSignal(true)
+	block := make(chan struct{})
+	started := &atomic.Int32{}
+	handlers.RegisterTerminationProcedure(func(os.Signal) error {
+		started.Add(1)
+		<-block
+		return nil
+	}, "hanging")
+	return handlers, codes, block, started
+}
+
+func TestHandlersForceExitsOnSecondSignalWithinWindow(t *testing.T) {
+	t.Parallel()
+	handlers, codes, block, started := newForceExitTest(t)
+	defer close(block)
+	handlers.SetForceExitWindow(time.Second)
+
+	handlers.Dispatch(syscall.SIGTERM)
+	assert.Eventually(t, func() bool { return started.Load() == 1 }, time.Second, time.Millisecond)
+	handlers.Dispatch(syscall.SIGINT)
+	select {
+	case code := <-codes:
+		assert.Equal(t, DefaultForceExitCode, code)
+	case <-time.After(time.Second):
+		t.Fatal("second signal did not force exit")
+	}
+	assert.Equal(t, int32(1), started.Load())
+}
+
+func TestHandlersStartsFreshTerminationOutsideForceExitWindow(t *testing.T) {
+	t.Parallel()
+	handlers, codes, block, started := newForceExitTest(t)
+	handlers.SetForceExitWindow(time.Millisecond * 10)
+
+	handlers.Dispatch(syscall.SIGTERM)
+	time.Sleep(time.Millisecond * 30)
+	handlers.Dispatch(syscall.SIGTERM)
+	time.Sleep(time.Millisecond * 20)
+	assert.Equal(t, int32(1), started.Load())
+	assert.Len(t, codes, 0)
+
+	close(block)
+	assert.Equal(t, 0, <-codes)
+	assert.Equal(t, 0, <-codes)
+	assert.Equal(t, int32(2), started.Load())
+}
+
+func TestHandlersFreshTerminationIsNotEndedByThePreviousOne(t *testing.T) {
+	t.Parallel()
+	codes := make(chan int, 4)
+	handlers := _newHandlers(func(code int) { codes <- code })
+	handlers.SetLogger(nopTestLogger{})
+	handlers.SetForceExitOnSecondSignal(true)
+	handlers.SetForceExitWindow(time.Millisecond * 50)
+	running, maxRunning := &atomic.Int32{}, &atomic.Int32{}
+	handlers.RegisterTerminationProcedure(func(os.Signal) error {
+		n := running.Add(1)
+		if n > maxRunning.Load() {
+			maxRunning.Store(n)
+		}
+		time.Sleep(time.Millisecond * 100)
+		running.Add(-1)
+		return nil
+	}, "slow")
+
+	handlers.Dispatch(syscall.SIGTERM)
+	time.Sleep(time.Millisecond * 70)
+	handlers.Dispatch(syscall.SIGTERM)
+	assert.Equal(t, 0, <-codes)
+	// the fresh termination is still running, so a signal within its window forces exit
+	handlers.Dispatch(syscall.SIGTERM)
+	assert.Equal(t, DefaultForceExitCode, <-codes)
+	assert.Equal(t, 0, <-codes)
+	assert.Equal(t, int32(1), maxRunning.Load())
+}
+
+func TestHandlersForceExitWindowDefaultsToShutdownTimeout(t *testing.T) {
+	t.Parallel()
+	handlers := _newHandlers(nil)
+	handlers.SetShutdownTimeout(time.Second)
+	now := time.Now()
+	handlers.terminationStart = now
+	assert.True(t, handlers.withinForceExitWindow(now.Add(time.Second)))
+	assert.False(t, handlers.withinForceExitWindow(now.Add(time.Second*2)))
+
+	handlers.SetForceExitWindow(0)
+	assert.True(t, handlers.withinForceExitWindow(now.Add(time.Hour)))
+}
+
+func TestHandlersForceExitsOnceShutdownDeadlineExceeded(t *testing.T) {
+	t.Parallel()
+	codes := make(chan int, 2)
+	logger := &recordingLogger{}
+	handlers := _newHandlers(func(code int) { codes <- code })
+	handlers.SetLogger(logger)
+	handlers.SetShutdownDeadline(time.Millisecond * 20)
+	handlers.SetForceExitCode(9)
+	block := make(chan struct{})
+	handlers.RegisterTerminationProcedure(func(os.Signal) error { return nil }, "fast")
+	// final procedures are not bound to the shutdown context, so it is stuck until the deadline forces exit
+	handlers.RegisterFinalProcedure(func(os.Signal) error {
+		<-block
+		return nil
+	}, "stuck")
+
+	go handlers.handleSignal(syscall.SIGTERM)
+	select {
+	case code := <-codes:
+		assert.Equal(t, 9, code)
+	case <-time.After(time.Second):
+		t.Fatal("shutdown deadline did not force exit")
+	}
+	assert.True(t, logger.contains("shutdown deadline exceeded while running termination procedure: stuck, force exiting"))
+	close(block)
+	assert.Equal(t, 0, <-codes)
+}
+
+func TestHandlersShutdownDeadlineBoundsProcedureContext(t *testing.T) {
+	t.Parallel()
+	codes := make(chan int, 2)
+	handlers := _newHandlers(func(code int) { codes <- code })
+	handlers.SetLogger(nopTestLogger{})
+	handlers.SetShutdownDeadline(time.Millisecond * 50)
+	deadlines := make(chan time.Time, 1)
+	released := make(chan struct{})
+	handlers.RegisterTerminationProcedureCtx(func(ctx context.Context, sig os.Signal) error {
+		deadline, _ := ctx.Deadline()
+		deadlines <- deadline
+		<-ctx.Done()
+		close(released)
+		return nil
+	}, "waiting for the deadline")
+
+	start := time.Now()
+	go handlers.handleSignal(syscall.SIGTERM)
+	deadline := <-deadlines
+	assert.WithinDuration(t, start.Add(time.Millisecond*50), deadline, time.Millisecond*20)
+	select {
+	case <-released:
+	case <-time.After(time.Second):
+		t.Fatal("procedure context not done by the shutdown deadline")
+	}
+	<-codes
+}
+
+func TestHandlersShutdownDeadlineStopsOnceDone(t *testing.T) {
+	t.Parallel()
+	codes := make(chan int, 2)
+	handlers := _newHandlers(func(code int) { codes <- code })
+	handlers.SetLogger(nopTestLogger{})
+	handlers.SetShutdownDeadline(time.Millisecond * 20)
+	handlers.handleSignal(syscall.SIGTERM)
+	time.Sleep(time.Millisecond * 40)
+	assert.Equal(t, 0, <-codes)
+	assert.Len(t, codes, 0)
+}
+
+func TestHandlersDebouncesRepeatedTerminationSignals(t *testing.T) {
+	t.Parallel()
+	handlers := _newHandlers(nil)
+	handlers.SetLogger(nopTestLogger{})
+	handlers.SetTerminationDebounce(time.Millisecond * 50)
+	runs := 0
+	handlers.RegisterTerminationProcedure(func(os.Signal) error {
+		runs++
+		return nil
+	}, "")
+
+	handlers.Dispatch(syscall.SIGTERM)
+	handlers.Dispatch(syscall.SIGTERM)
+	assert.Equal(t, 1, runs)
+
+	time.Sleep(time.Millisecond * 60)
+	handlers.Dispatch(syscall.SIGINT)
+	assert.Equal(t, 2, runs)
+}
+
+func TestHandlersDropsTerminationSignalWhileTerminating(t *testing.T) {
+	t.Parallel()
+	logger := &recordingLogger{}
+	codes := make(chan int, 2)
+	handlers := _newHandlers(func(code int) { codes <- code })
+	handlers.SetLogger(logger)
+	block := make(chan struct{})
+	started := &atomic.Int32{}
+	handlers.RegisterTerminationProcedure(func(os.Signal) error {
+		started.Add(1)
+		<-block
+		return nil
+	}, "hanging")
+	handled := make(chan struct{})
+	handlers.RegisterSignalHandler(func(os.Signal) { close(handled) }, syscall.SIGUSR1)
+
+	for i := 0; i < 2; i++ {
+		go handlers.Dispatch(syscall.SIGTERM)
+	}
+	assert.Eventually(t, func() bool {
+		return logger.contains("warn: termination already in progress, signal dropped: terminated")
+	}, time.Second, time.Millisecond)
+	handlers.Dispatch(syscall.SIGUSR1)
+	<-handled
+
+	close(block)
+	assert.Equal(t, 0, <-codes)
+	assert.Equal(t, int32(1), started.Load())
+	assert.Len(t, codes, 0)
+
+	handlers.Dispatch(syscall.SIGTERM)
+	assert.Equal(t, int32(2), started.Load())
+}
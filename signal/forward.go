@@ -0,0 +1,39 @@
+package signal
+
+import (
+	"errors"
+	"os"
+)
+
+// ForwardTo registers a handler sending all or given signal(s) to the process of pid, e.g. to relay signals to children
+// of a supervisor. The returned func stops forwarding.
+// NOTE: signals are forwarded before any other handler is called, so children are signalled before termination
+// procedures run. A process which has already exited is logged and skipped.
+func (s *Handlers) ForwardTo(pid int, signals ...os.Signal) (deregister func()) {
+	entry := &handlerEntry{fn: func(sig os.Signal) {
+		s.forward(pid, sig)
+	}}
+	if len(signals) == 0 {
+		signals = []os.Signal{anySignal}
+	}
+
+	s.globalLock.Lock()
+	s.hasUserHandlers.Store(true)
+	for _, sig := range signals {
+		s.handlers[sig] = append([]*handlerEntry{entry}, s.handlers[sig]...)
+	}
+	s.globalLock.Unlock()
+	return s.deregisterFunc(entry)
+}
+
+func (s *Handlers) forward(pid int, sig os.Signal) {
+	err := signalProcess(pid, sig)
+	switch {
+	case errors.Is(err, os.ErrProcessDone):
+		s.log.Info("process ", pid, " already exited, signal not forwarded: ", sig)
+	case err != nil:
+		logError(s.log, "error while forwarding signal ", sig, " to process ", pid, ": ", err)
+	default:
+		s.log.Debug("signal forwarded to process ", pid, ": ", sig)
+	}
+}
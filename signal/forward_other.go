@@ -0,0 +1,15 @@
+//go:build !unix
+
+package signal
+
+import "os"
+
+// signalProcess sends sig to the process of pid.
+// NOTE: only os.Kill can be sent on Windows.
+func signalProcess(pid int, sig os.Signal) error {
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return err
+	}
+	return process.Signal(sig)
+}
@@ -0,0 +1,40 @@
+//go:build unix
+
+package signal
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandlersForwardToSignalsChildBeforeTermination(t *testing.T) {
+	t.Parallel()
+	child := exec.Command("sleep", "10")
+	if !assert.NoError(t, child.Start()) {
+		return
+	}
+	logger := &recordingLogger{}
+	handlers := _newHandlers(nil)
+	handlers.SetLogger(logger)
+	forwarded := false
+	handlers.RegisterTerminationProcedure(func(os.Signal) error {
+		forwarded = logger.contains("debug: signal forwarded to process ")
+		return nil
+	}, "")
+	handlers.ForwardTo(child.Process.Pid, syscall.SIGTERM)
+
+	handlers.handleSignal(syscall.SIGTERM)
+	assert.True(t, forwarded)
+	err := child.Wait()
+	if exitErr, ok := err.(*exec.ExitError); assert.True(t, ok, err) {
+		status := exitErr.Sys().(syscall.WaitStatus)
+		assert.Equal(t, syscall.SIGTERM, status.Signal())
+	}
+
+	handlers.handleSignal(syscall.SIGTERM)
+	assert.True(t, logger.contains("already exited, signal not forwarded: terminated"))
+}
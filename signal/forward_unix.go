@@ -0,0 +1,24 @@
+//go:build unix
+
+package signal
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// signalProcess sends sig to the process of pid, os.ErrProcessDone is returned if it has already exited.
+func signalProcess(pid int, sig os.Signal) error {
+	num, ok := sig.(syscall.Signal)
+	if !ok {
+		return fmt.Errorf("not a system signal: %v", sig)
+	}
+	if err := syscall.Kill(pid, num); err != nil {
+		if err == syscall.ESRCH {
+			return os.ErrProcessDone
+		}
+		return err
+	}
+	return nil
+}
@@ -3,26 +3,112 @@ package signal
 
 import (
 	"context"
+	"errors"
+	"io"
 	"os"
 	"os/signal"
+	"runtime/debug"
+	"sort"
 	"sync"
+	"sync/atomic"
 	"syscall"
+	"time"
 )
 
-// DefaultTerminationSignals is used when user doesn't provide their own.
-var DefaultTerminationSignals = []os.Signal{syscall.SIGTERM, syscall.SIGINT}
-
 // HandlerFunc is a callback of signal.
 type HandlerFunc func(os.Signal)
 
+// SignalMatcher reports whether the received signal got should be dispatched to handlers registered for registered.
+type SignalMatcher func(got, registered os.Signal) bool
+
 // Handlers handles OS's signals.
 type Handlers struct {
 	globalLock            sync.RWMutex
 	log                   Logger
 	exit                  func(int)
-	handlers              map[os.Signal][]HandlerFunc
+	matchSignal           SignalMatcher
+	customMatcher         bool
+	handlers              map[os.Signal][]*handlerEntry
+	handlerSeq            uint64
+	startupHandlers       map[os.Signal][]*handlerEntry
+	startupComplete       bool
+	anySkipsTermination   bool
+	hasRemovedHandlers    atomic.Bool
+	hasUserHandlers       atomic.Bool
+	maxOccupancy          atomic.Int64
+	fullObservations      atomic.Uint64
 	terminationSignals    []os.Signal
 	terminationProcedures []terminationProcedure
+	lastProcedureID       ProcedureID
+	shutdownTimeout       time.Duration
+	signalSource          <-chan os.Signal
+	listening             int
+	signalBufferSize      int
+	noDefaultTermination  bool
+	listenContext         context.Context
+	listenCancel          context.CancelFunc
+	listenSignal          *receivedSignal
+	softBudget            time.Duration
+	hardBudget            time.Duration
+	queueSize             int
+	queuePolicy           DropPolicy
+	queueDrops            [dropPolicyCount]atomic.Uint64
+	signalLogLevels       map[os.Signal]Level
+	ignored               map[os.Signal]struct{}
+	panicExitCode         int
+	repanic               bool
+	captureStacks         bool
+	quitDumpWriter        io.Writer
+	tracer                Tracer
+	metrics               Metrics
+	parallelTermination   bool
+	exitCodeStrategy      ExitCodeStrategy
+	defaultErrorCode      int
+	exitCodeExtractor     func(error) (int, bool)
+	messages              Messages
+	tracked               DrainTracker
+	observers             []ShutdownObserver
+	finalProcedures       []terminationProcedure
+	onComplete            func(ShutdownReport)
+	onShutdownStart       func(os.Signal)
+	onShutdownComplete    func(int)
+	preExit               func(int)
+	startTime             time.Time
+
+	terminationLock     sync.Mutex
+	terminating         bool
+	terminationDone     chan struct{}
+	terminationStart    time.Time
+	forceExit           bool
+	forceExitWindow     time.Duration
+	forceExitWindowSet  bool
+	forceExitCode       int
+	shutdownDeadline    time.Duration
+	inFlight            atomic.Value
+	terminationDebounce time.Duration
+	lastTermination     time.Time
+
+	eventsLock   sync.Mutex
+	events       chan Event
+	eventsClosed bool
+	subscribers  []chan os.Signal
+	// observed is set once Events or Notify is called, so signals skip eventsLock until then.
+	observed atomic.Bool
+
+	terminatedOnce sync.Once
+	terminated     chan struct{}
+	terminatedCode int
+
+	runLock     sync.Mutex
+	lastRun     *shutdownRun
+	gracefulRun *shutdownRun
+
+	// signalCounts maps signals to their *atomic.Uint64 count.
+	signalCounts sync.Map
+
+	reportLock   sync.Mutex
+	lastReport   ShutdownReport
+	reportWriter io.Writer
 }
 
 type _anySignal struct{}
@@ -35,126 +121,1129 @@ func (_anySignal) String() string {
 
 var anySignal = _anySignal{}
 
+// handlerEntry is a registered HandlerFunc, the same entry is shared by all signals it is registered for.
+type handlerEntry struct {
+	fn      HandlerFunc
+	removed atomic.Bool
+	// except holds the signals a handler registered to all signals is not called for.
+	except []os.Signal
+	// termination marks the handler of termination signals installed by Handlers.
+	termination bool
+	// seq is the registration order of the handler, zero for handlers prepended to be called first.
+	seq uint64
+}
+
 // NewHandlers creates new Handlers with termination signals set to DefaultTerminationSignals or given signals.
+// NOTE: it is kept for compatibility, NewHandlersWithOptions configures Handlers before it can be used by others.
 func NewHandlers(terminationSignals ...os.Signal) *Handlers {
-	if len(terminationSignals) == 0 {
-		terminationSignals = DefaultTerminationSignals
-	}
+	return newHandlers(WithTerminationSignals(terminationSignals...))
+}
+
+// NewHandlersWithOptions creates a new Handlers configured by opts, it is the same as NewHandlers if none given.
+func NewHandlersWithOptions(opts ...Option) *Handlers {
+	return newHandlers(opts...)
+}
+
+func newHandlers(opts ...Option) *Handlers {
 	handlers := &Handlers{
-		terminationSignals: terminationSignals,
-		handlers:           make(map[os.Signal][]HandlerFunc, len(terminationSignals)+1),
+		terminationSignals: DefaultTerminationSignals,
 		log:                stdLogger{},
 		exit:               os.Exit,
+		matchSignal:        defaultSignalMatcher,
+		panicExitCode:      DefaultPanicExitCode,
+		forceExitCode:      DefaultForceExitCode,
+		defaultErrorCode:   1,
+		messages:           DefaultMessages,
+		metrics:            nopMetrics{},
+		signalBufferSize:   1,
+		startTime:          time.Now(),
+		terminated:         make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(handlers)
 	}
-	handlers.handlers[anySignal] = make([]HandlerFunc, 0)
+	handlers.handlers = make(map[os.Signal][]*handlerEntry, len(handlers.terminationSignals)+1)
+	handlers.handlers[anySignal] = make([]*handlerEntry, 0)
+	handlers.startupHandlers = make(map[os.Signal][]*handlerEntry)
 	handlers.installTerminationHandlers()
 	return handlers
 }
 
 func (s *Handlers) installTerminationHandlers() {
-	s.RegisterSignalHandler(s.handleTerminationSignals, s.terminationSignals...)
+	s.globalLock.Lock()
+	defer s.globalLock.Unlock()
+	s.installTerminationHandlersLocked()
+}
+
+func (s *Handlers) installTerminationHandlersLocked() {
+	if s.noDefaultTermination {
+		return
+	}
+	s.addHandlerEntryLocked(s.handlers, &handlerEntry{fn: s.handleTerminationSignals, termination: true}, s.terminationSignals...)
 }
 
-// RegisterSignalHandler registers handler as a callback of all or given signal(s).
+// SetTerminationSignals replaces the termination signals, e.g. once they are read from config, DefaultTerminationSignals
+// is used if none given. Other handlers of the former termination signals are kept.
+// NOTE: StartListenSelective selects signals when it is called, it has to be called again to listen to new ones.
+func (s *Handlers) SetTerminationSignals(signals ...os.Signal) {
+	if len(signals) == 0 {
+		signals = DefaultTerminationSignals
+	}
+	s.globalLock.Lock()
+	defer s.globalLock.Unlock()
+	for _, entries := range s.handlers {
+		for _, entry := range entries {
+			if entry.termination {
+				entry.removed.Store(true)
+			}
+		}
+	}
+	s.purgeRemovedHandlersLocked()
+	s.terminationSignals = append([]os.Signal(nil), signals...)
+	s.installTerminationHandlersLocked()
+}
+
+// Reset drops all registered signal handlers, startup handlers and termination procedures, termination signals are
+// still handled so s remains usable, unless it is created with WithoutDefaultTermination.
+func (s *Handlers) Reset() {
+	s.globalLock.Lock()
+	defer s.globalLock.Unlock()
+	s.clearLocked()
+	s.installTerminationHandlersLocked()
+	s.log.Debug("handlers reset")
+}
+
+// Clear drops everything like Reset, including the handling of termination signals.
+// NOTE: termination signals no longer terminate the process afterwards, until Reset is called.
+func (s *Handlers) Clear() {
+	s.globalLock.Lock()
+	defer s.globalLock.Unlock()
+	s.clearLocked()
+	s.log.Debug("handlers cleared")
+}
+
+func (s *Handlers) clearLocked() {
+	s.handlers = map[os.Signal][]*handlerEntry{anySignal: make([]*handlerEntry, 0)}
+	s.startupHandlers = make(map[os.Signal][]*handlerEntry)
+	s.terminationProcedures = nil
+	s.finalProcedures = nil
+	s.hasUserHandlers.Store(false)
+}
+
+// RegisterSignalHandler registers handler as a callback of all or given signal(s), the returned func deregisters it
+// from all of them.
 // NOTE: if multiple handlers are registered for a single signal, the handlers will be called in registered order, handlers registered to all signals are called first.
-func (s *Handlers) RegisterSignalHandler(handler HandlerFunc, signals ...os.Signal) {
+func (s *Handlers) RegisterSignalHandler(handler HandlerFunc, signals ...os.Signal) (deregister func()) {
+	entry := &handlerEntry{fn: handler}
+	s.registerHandlerEntry(entry, signals...)
+	return s.deregisterFunc(entry)
+}
+
+// RegisterSignalHandlerExcept registers handler as a callback of all signals but the given ones, e.g. to log every
+// signal which is not handled elsewhere. The returned func deregisters it.
+// NOTE: it is called like handlers registered to all signals, i.e. before handlers registered to given signals.
+func (s *Handlers) RegisterSignalHandlerExcept(handler HandlerFunc, except ...os.Signal) (deregister func()) {
+	entry := &handlerEntry{fn: handler, except: append([]os.Signal(nil), except...)}
+	s.registerHandlerEntry(entry)
+	return s.deregisterFunc(entry)
+}
+
+// RegisterSignalHandlerWithState registers handler like RegisterSignalHandler, handler is given state on each call,
+// e.g. a shared pool or config, instead of closing over it. The returned func deregisters it.
+func (s *Handlers) RegisterSignalHandlerWithState(handler func(sig os.Signal, state interface{}), state interface{},
+	signals ...os.Signal) (deregister func()) {
+	return s.RegisterSignalHandler(func(sig os.Signal) {
+		handler(sig, state)
+	}, signals...)
+}
+
+// RegisterSignalHandlerSelf registers handler like RegisterSignalHandler, handler is given a deregister func to remove itself.
+// NOTE: once deregister is called the handler is never called again, it is removed from all its signals after the current dispatch completes.
+func (s *Handlers) RegisterSignalHandlerSelf(handler func(sig os.Signal, deregister func()), signals ...os.Signal) {
+	entry := &handlerEntry{}
+	deregister := s.deregisterFunc(entry)
+	entry.fn = func(sig os.Signal) {
+		handler(sig, deregister)
+	}
+	s.registerHandlerEntry(entry, signals...)
+}
+
+// RegisterSignalHandlerOnce registers handler like RegisterSignalHandler, handler is called at most once then deregistered.
+// NOTE: if handler is registered for several signals, the first of them received deregisters it from all of them;
+// register it once per signal to keep them independent.
+func (s *Handlers) RegisterSignalHandlerOnce(handler HandlerFunc, signals ...os.Signal) {
+	entry := &handlerEntry{}
+	entry.fn = func(sig os.Signal) {
+		if !entry.removed.CompareAndSwap(false, true) {
+			return
+		}
+		s.hasRemovedHandlers.Store(true)
+		handler(sig)
+	}
+	s.registerHandlerEntry(entry, signals...)
+}
+
+// deregisterFunc returns the func deregistering entry, it is safe to call at any time and more than once.
+// The entry is dropped at once unless the lock is busy, it is dropped after the next dispatch then.
+func (s *Handlers) deregisterFunc(entry *handlerEntry) func() {
+	return func() {
+		entry.removed.Store(true)
+		s.hasRemovedHandlers.Store(true)
+		if s.globalLock.TryLock() {
+			s.hasRemovedHandlers.Store(false)
+			s.purgeRemovedHandlersLocked()
+			s.globalLock.Unlock()
+		}
+	}
+}
+
+func (s *Handlers) registerHandlerEntry(entry *handlerEntry, signals ...os.Signal) {
 	s.globalLock.Lock()
 	defer s.globalLock.Unlock()
+	s.hasUserHandlers.Store(true)
+	s.addHandlerEntryLocked(s.handlers, entry, signals...)
+}
+
+func (s *Handlers) addHandlerEntryLocked(registry map[os.Signal][]*handlerEntry, entry *handlerEntry, signals ...os.Signal) {
+	s.handlerSeq++
+	entry.seq = s.handlerSeq
 	if len(signals) == 0 {
-		s.handlers[anySignal] = append(s.handlers[anySignal], handler)
+		registry[anySignal] = append(registry[anySignal], entry)
 		return
 	}
 
 	for _, sig := range signals {
-		if handlers, exists := s.handlers[sig]; exists {
-			s.handlers[sig] = append(handlers, handler)
-		} else {
-			s.handlers[sig] = []HandlerFunc{handler}
+		registry[sig] = append(registry[sig], entry)
+	}
+}
+
+// RegisterStartupSignalHandler registers handler as a callback of all or given signal(s) until MarkStartupComplete is called.
+// NOTE: startup handlers are called before any other handler, e.g. to abort startup on SIGTERM before termination procedures run.
+// Registering after MarkStartupComplete is a no-op.
+func (s *Handlers) RegisterStartupSignalHandler(handler HandlerFunc, signals ...os.Signal) {
+	s.globalLock.Lock()
+	defer s.globalLock.Unlock()
+	if s.startupComplete {
+		s.log.Debug("startup is complete, startup signal handler is ignored")
+		return
+	}
+	s.hasUserHandlers.Store(true)
+	s.addHandlerEntryLocked(s.startupHandlers, &handlerEntry{fn: handler}, signals...)
+}
+
+// MarkStartupComplete ends the startup phase, handlers registered by RegisterStartupSignalHandler are deregistered.
+func (s *Handlers) MarkStartupComplete() {
+	s.globalLock.Lock()
+	defer s.globalLock.Unlock()
+	s.startupComplete = true
+	s.startupHandlers = nil
+	s.log.Debug("startup complete")
+}
+
+// purgeRemovedHandlers drops deregistered entries.
+func (s *Handlers) purgeRemovedHandlers() {
+	if !s.hasRemovedHandlers.Swap(false) {
+		return
+	}
+
+	s.globalLock.Lock()
+	defer s.globalLock.Unlock()
+	s.purgeRemovedHandlersLocked()
+}
+
+func (s *Handlers) purgeRemovedHandlersLocked() {
+	for sig, entries := range s.handlers {
+		kept := entries[:0]
+		for _, entry := range entries {
+			if !entry.removed.Load() {
+				kept = append(kept, entry)
+			}
 		}
+		s.handlers[sig] = kept
 	}
 }
 
 // RegisterTerminationProcedure registers given fn as a handler of termination signals, messages are logged before fn called.
-func (s *Handlers) RegisterTerminationProcedure(fn TerminationFunc, message string) {
+// The returned ID deregisters it by RemoveTerminationProcedure, so do the IDs returned by other registration methods.
+func (s *Handlers) RegisterTerminationProcedure(fn TerminationFunc, message string) ProcedureID {
+	return s.RegisterTerminationProcedureInStage(fn, message, "")
+}
+
+// RegisterTerminationProcedureInStage registers given fn as a handler of termination signals in the given stage.
+// NOTE: stages run in the order they are first registered, procedures within a stage run by priority,
+// then in registered order, see RegisterTerminationProcedureWithPriority.
+// Procedures registered by RegisterTerminationProcedure belong to the "" stage.
+func (s *Handlers) RegisterTerminationProcedureInStage(fn TerminationFunc, message, stage string) ProcedureID {
+	return s.addTerminationProcedure(terminationProcedure{fn: fn.withContext(), message: message, stage: stage})
+}
+
+// RegisterTerminationProcedureCtx registers given fn as a handler of termination signals like RegisterTerminationProcedure.
+// The context given to fn carries the values of the context given to StartListenContext, if any, and has the shutdown
+// timeout as its deadline, e.g. it can be passed to http.Server.Shutdown as is.
+func (s *Handlers) RegisterTerminationProcedureCtx(fn TerminationFuncCtx, message string) ProcedureID {
+	return s.addTerminationProcedure(terminationProcedure{fn: fn, message: message})
+}
+
+// RegisterTerminationProcedureWithTimeout registers given fn as a handler of termination signals which is abandoned
+// once it runs longer than timeout, the shutdown goes on with the next procedure then.
+// NOTE: an abandoned procedure keeps running in its goroutine until the process exits.
+func (s *Handlers) RegisterTerminationProcedureWithTimeout(fn TerminationFunc, message string, timeout time.Duration) ProcedureID {
+	return s.RegisterTerminationProcedureWithOptions(fn, ProcedureOptions{Message: message, Timeout: timeout})
+}
+
+// RegisterTerminationProcedureWithPriority registers given fn as a handler of termination signals with priority,
+// procedures with lower priority run first, procedures registered without priority have priority 0.
+// NOTE: procedures with the same priority run in registered order.
+func (s *Handlers) RegisterTerminationProcedureWithPriority(fn TerminationFunc, message string, priority int) ProcedureID {
+	return s.RegisterTerminationProcedureWithOptions(fn, ProcedureOptions{Message: message, Priority: priority})
+}
+
+// RegisterTerminationProcedureWithOptions registers given fn as a handler of termination signals configured by opts.
+func (s *Handlers) RegisterTerminationProcedureWithOptions(fn TerminationFunc, opts ProcedureOptions) ProcedureID {
+	return s.addTerminationProcedure(terminationProcedure{
+		fn:                   fn.withContext(),
+		message:              opts.Message,
+		stage:                opts.Stage,
+		priority:             opts.Priority,
+		timeout:              opts.Timeout,
+		ignoreGlobalDeadline: opts.IgnoreGlobalDeadline,
+	})
+}
+
+func (s *Handlers) addTerminationProcedure(proc terminationProcedure) ProcedureID {
+	return s.addTerminationProcedures(proc)[0]
+}
+
+func (s *Handlers) addTerminationProcedures(procs ...terminationProcedure) []ProcedureID {
+	ids := make([]ProcedureID, 0, len(procs))
 	s.globalLock.Lock()
-	s.terminationProcedures = append(s.terminationProcedures, terminationProcedure{fn, message})
+	for i := range procs {
+		procs[i].id = s.newProcedureIDLocked()
+		ids = append(ids, procs[i].id)
+	}
+	s.terminationProcedures = append(s.terminationProcedures, procs...)
 	s.globalLock.Unlock()
-	s.log.Debug("registered termination procedure for: ", message)
+	for _, proc := range procs {
+		s.log.Debug("registered termination procedure for: ", proc.message)
+	}
+	return ids
+}
+
+func (s *Handlers) newProcedureIDLocked() ProcedureID {
+	s.lastProcedureID++
+	return s.lastProcedureID
+}
+
+// RemoveTerminationProcedure deregisters the termination procedure of id, it returns false if there is none.
+// The remaining procedures keep their order, a shutdown in progress keeps using the procedures registered when it started.
+func (s *Handlers) RemoveTerminationProcedure(id ProcedureID) bool {
+	s.globalLock.Lock()
+	defer s.globalLock.Unlock()
+	for i, proc := range s.terminationProcedures {
+		if proc.id != id {
+			continue
+		}
+		remaining := make([]terminationProcedure, 0, len(s.terminationProcedures)-1)
+		remaining = append(remaining, s.terminationProcedures[:i]...)
+		s.terminationProcedures = append(remaining, s.terminationProcedures[i+1:]...)
+		s.log.Debug("removed termination procedure for: ", proc.message)
+		return true
+	}
+	return false
+}
+
+// ReplaceTerminationProcedures replaces all registered termination procedures with procs at once.
+// NOTE: a shutdown in progress keeps using the procedures registered when it started.
+func (s *Handlers) ReplaceTerminationProcedures(procs []NamedProcedure) {
+	replacement := make([]terminationProcedure, 0, len(procs))
+	for _, proc := range procs {
+		replacement = append(replacement, terminationProcedure{fn: proc.Fn.withContext(), message: proc.Name, stage: proc.Stage})
+	}
+
+	s.globalLock.Lock()
+	for i := range replacement {
+		replacement[i].id = s.newProcedureIDLocked()
+	}
+	s.terminationProcedures = replacement
+	s.globalLock.Unlock()
+	s.log.Debug("replaced termination procedures, count: ", len(replacement))
+}
+
+// SetShutdownTimeout sets the time limit of running all termination procedures, zero means no limit.
+// NOTE: once the timeout fires, the running procedure is abandoned and the remaining ones are skipped.
+func (s *Handlers) SetShutdownTimeout(d time.Duration) {
+	s.globalLock.Lock()
+	defer s.globalLock.Unlock()
+	s.shutdownTimeout = d
+}
+
+// SetParallelTermination sets whether termination procedures of the same stage run concurrently, stages still run
+// one after another. The exit code is still selected from the first failed procedure in registration order.
+// NOTE: procedures running concurrently must not depend on each other.
+func (s *Handlers) SetParallelTermination(parallel bool) {
+	s.globalLock.Lock()
+	defer s.globalLock.Unlock()
+	s.parallelTermination = parallel
+}
+
+// SetCaptureStacksOnTimeout sets whether the stack of a timed out termination procedure is logged and reported.
+// NOTE: it is off by default since stacks are verbose.
+func (s *Handlers) SetCaptureStacksOnTimeout(capture bool) {
+	s.globalLock.Lock()
+	defer s.globalLock.Unlock()
+	s.captureStacks = capture
+}
+
+// LastShutdownReport returns the report of the latest run of termination procedures.
+func (s *Handlers) LastShutdownReport() ShutdownReport {
+	s.reportLock.Lock()
+	defer s.reportLock.Unlock()
+	return s.lastReport
+}
+
+// SimulateShutdown runs termination procedures for sig like a real termination but never exits, then returns the report.
+// It helps to measure the shutdown path under load, it can be called repeatedly and leaves no state behind,
+// i.e. LastShutdownReport and the report writer are left untouched.
+func (s *Handlers) SimulateShutdown(sig os.Signal) ShutdownReport {
+	s.log.Info("simulating shutdown for signal: ", sig)
+	return s.runTerminationProcedures(sig)
 }
 
 // StartListen starts listen to all signals.
 // NOTE: termination signals are not required in given signals.
 func (s *Handlers) StartListen() context.CancelFunc {
-	s.log.Debug("start listening to all signals")
-	c := make(chan os.Signal, 1)
-	signal.Notify(c)
-	go func() {
-		for sig := range c {
-			s.log.Info("signal received: ", sig)
-			s.handleSignal(sig)
+	s.globalLock.Lock()
+	s.listening++
+	s.globalLock.Unlock()
+	return s.untrackListening(s.startListen())
+}
+
+func (s *Handlers) startListen() func() {
+	if s.signalSource != nil {
+		s.log.Debug("start listening to the signal source")
+		stopped := make(chan struct{})
+		go s.listen(s.signalSource, stopped)
+		return func() {
+			close(stopped)
 		}
-	}()
+	}
+
+	s.log.Debug("start listening to all signals")
+	return s.notify()
+}
+
+// Started reports whether s is listening to signals, i.e. StartListen or its variants are called and the returned
+// func is not yet.
+func (s *Handlers) Started() bool {
+	s.globalLock.RLock()
+	defer s.globalLock.RUnlock()
+	return s.listening > 0
+}
+
+// untrackListening returns the func calling stop once, then no longer counting the listening as active.
+func (s *Handlers) untrackListening(stop func()) context.CancelFunc {
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			stop()
+			s.globalLock.Lock()
+			s.listening--
+			s.globalLock.Unlock()
+		})
+	}
+}
+
+// ErrAlreadyListening is returned by StartListenOnce while the previous listening is not stopped.
+var ErrAlreadyListening = errors.New("already listening to signals")
+
+// StartListenOnce starts listening like StartListen, but fails with ErrAlreadyListening if it is already listening,
+// by StartListenOnce or any other variant. Listening can be started again once the returned funcs are called.
+func (s *Handlers) StartListenOnce() (context.CancelFunc, error) {
+	s.globalLock.Lock()
+	if s.listening > 0 {
+		s.globalLock.Unlock()
+		return nil, ErrAlreadyListening
+	}
+	s.listening++
+	s.globalLock.Unlock()
+	return s.untrackListening(s.startListen()), nil
+}
+
+// StartListenSelective starts listening like StartListen, but only to termination signals, ignored signals and signals
+// with a registered handler, other signals keep their default behavior, e.g. SIGCHLD or SIGWINCH are not trapped.
+// NOTE: signals are selected when it is called, handlers registered for other signals afterwards are never called,
+// neither are handlers registered to all signals for unselected signals.
+func (s *Handlers) StartListenSelective() context.CancelFunc {
+	if s.signalSource != nil {
+		return s.StartListen()
+	}
+
+	signals := s.selectedSignals()
+	s.log.Debug("start listening to signals: ", signals)
+	s.globalLock.Lock()
+	s.listening++
+	s.globalLock.Unlock()
+	return s.untrackListening(s.notify(signals...))
+}
+
+// notify relays signals, or all of them if none given, to handlers until the returned func is called.
+func (s *Handlers) notify(signals ...os.Signal) context.CancelFunc {
+	s.globalLock.RLock()
+	c := make(chan os.Signal, s.signalBufferSize)
+	s.globalLock.RUnlock()
+	signal.Notify(c, signals...)
+	go s.listen(c, nil)
 	return func() {
 		signal.Stop(c)
 		close(c)
 	}
 }
 
-func (s *Handlers) handleSignal(target os.Signal) {
+// selectedSignals returns termination signals, ignored signals and signals with a registered handler.
+func (s *Handlers) selectedSignals() []os.Signal {
 	s.globalLock.RLock()
 	defer s.globalLock.RUnlock()
-	for _, handle := range s.handlers[anySignal] {
-		handle(target)
+	signals := append([]os.Signal(nil), s.terminationSignals...)
+	for _, registry := range []map[os.Signal][]*handlerEntry{s.startupHandlers, s.handlers} {
+		for sig, entries := range registry {
+			if sig != anySignal && len(entries) > 0 && !containsSignal(signals, sig) {
+				signals = append(signals, sig)
+			}
+		}
+	}
+	for sig := range s.ignored {
+		if !containsSignal(signals, sig) {
+			signals = append(signals, sig)
+		}
+	}
+	return signals
+}
+
+func containsSignal(signals []os.Signal, sig os.Signal) bool {
+	for _, s := range signals {
+		if s == sig {
+			return true
+		}
 	}
+	return false
+}
 
-	for sig, handlers := range s.handlers {
-		if sig != target {
-			s.log.Debug("no handler found for signal: ", target)
-			continue
+// StartListenContext starts listening like StartListen, the returned context is cancelled once a termination signal is
+// received, before termination procedures run. The signal can be retrieved from it by FromContext.
+// Listening stops when parent is done or the returned cancel func is called.
+// The context given to procedures registered by RegisterTerminationProcedureCtx carries the values of parent.
+func (s *Handlers) StartListenContext(parent context.Context) (context.Context, context.CancelFunc) {
+	received := &receivedSignal{}
+	ctx, cancel := context.WithCancel(context.WithValue(parent, receivedSignalKey{}, received))
+	s.globalLock.Lock()
+	s.listenContext, s.listenCancel, s.listenSignal = ctx, cancel, received
+	s.globalLock.Unlock()
+
+	stop := s.StartListen()
+	stopped := make(chan struct{})
+	var once sync.Once
+	stopAll := func() {
+		once.Do(func() {
+			stop()
+			cancel()
+			close(stopped)
+		})
+	}
+	// NOTE: not ctx, a termination signal cancels it while listening goes on, e.g. to force exit
+	go func() {
+		select {
+		case <-parent.Done():
+			stopAll()
+		case <-stopped:
+		}
+	}()
+	return ctx, stopAll
+}
+
+// shutdownContext returns the context given to termination procedures, it is done once the shutdown timeout fires
+// or the shutdown deadline is exceeded, whichever comes first.
+func (s *Handlers) shutdownContext() (context.Context, context.CancelFunc) {
+	s.globalLock.RLock()
+	base, timeout := s.listenContext, s.shutdownTimeout
+	s.globalLock.RUnlock()
+	s.terminationLock.Lock()
+	deadline := s.shutdownDeadline
+	s.terminationLock.Unlock()
+	if deadline > 0 && (timeout <= 0 || deadline < timeout) {
+		timeout = deadline
+	}
+	if base == nil {
+		base = context.Background()
+	}
+	base = context.WithoutCancel(base)
+	if timeout <= 0 {
+		return context.WithCancel(base)
+	}
+	return context.WithTimeout(base, timeout)
+}
+
+func (s *Handlers) listen(c <-chan os.Signal, stopped <-chan struct{}) {
+	receive := s.handleSignal
+	if queue := s.newSignalQueue(); queue != nil {
+		defer queue.close()
+		go queue.dispatch(s.handleSignal)
+		receive = func(sig os.Signal) {
+			if queue.push(sig) {
+				s.queueDrops[queue.policy].Add(1)
+				logWarn(s.log, "signal queue is full, signal dropped by policy ", queue.policy)
+			}
 		}
-		for _, handle := range handlers {
-			handle(target)
+	}
+
+	for {
+		select {
+		case sig, ok := <-c:
+			if !ok {
+				return
+			}
+			s.observeOccupancy(len(c), cap(c))
+			logAt(s.log, s.signalLogLevel(sig), "signal received: ", sig)
+			receive(sig)
+		case <-stopped:
+			return
 		}
 	}
 }
 
-func (s *Handlers) handleTerminationSignals(sig os.Signal) {
-	code := s.runTerminationProcedures(sig)
-	s.log.Info("bye")
-	s.exit(code)
+// SetSignalBufferSize sets how many received signals are buffered while a handler is running, 1 by default.
+// Signals beyond the buffer are dropped by the OS notification, so bursts are only all handled with a large enough buffer.
+// NOTE: it is applied on the next StartListen, sizes below 1 are treated as 1.
+func (s *Handlers) SetSignalBufferSize(n int) {
+	s.globalLock.Lock()
+	defer s.globalLock.Unlock()
+	if n < 1 {
+		n = 1
+	}
+	s.signalBufferSize = n
 }
 
-func (s *Handlers) runTerminationProcedures(sig os.Signal) int {
+// SetSignalLogLevel sets the level of the "signal received" line logged for sig, LevelInfo by default.
+func (s *Handlers) SetSignalLogLevel(sig os.Signal, level Level) {
+	s.globalLock.Lock()
+	defer s.globalLock.Unlock()
+	if s.signalLogLevels == nil {
+		s.signalLogLevels = make(map[os.Signal]Level)
+	}
+	s.signalLogLevels[sig] = level
+}
+
+func (s *Handlers) signalLogLevel(sig os.Signal) Level {
 	s.globalLock.RLock()
 	defer s.globalLock.RUnlock()
-	if len(s.terminationProcedures) == 0 {
-		s.log.Info("nothing to do before termination")
+	if level, exists := s.signalLogLevels[sig]; exists {
+		return level
+	}
+	return LevelInfo
+}
+
+// Ignore makes signals swallowed, neither handlers including the ones registered to all signals nor termination react
+// to them. StartListenSelective listens to them as well so their default behavior is suppressed, e.g. for SIGPIPE.
+func (s *Handlers) Ignore(signals ...os.Signal) {
+	s.globalLock.Lock()
+	defer s.globalLock.Unlock()
+	if s.ignored == nil {
+		s.ignored = make(map[os.Signal]struct{}, len(signals))
+	}
+	for _, sig := range signals {
+		s.ignored[sig] = struct{}{}
+	}
+}
+
+func (s *Handlers) isIgnored(sig os.Signal) bool {
+	s.globalLock.RLock()
+	defer s.globalLock.RUnlock()
+	_, ignored := s.ignored[sig]
+	return ignored
+}
+
+// observeOccupancy records the occupancy of the listening channel right after a signal is received from it, queued
+// signals are still in the channel. A channel which is still full is likely to have dropped signals.
+func (s *Handlers) observeOccupancy(queued, capacity int) {
+	occupancy := queued + 1
+	if capacity > 0 && occupancy > capacity {
+		// a signal arrived right after the receive
+		occupancy = capacity
+	}
+	for {
+		max := s.maxOccupancy.Load()
+		if int64(occupancy) <= max || s.maxOccupancy.CompareAndSwap(max, int64(occupancy)) {
+			break
+		}
+	}
+	// a channel of one signal is full whenever a signal is received, which tells nothing about drops
+	if capacity > 1 && queued >= capacity {
+		s.fullObservations.Add(1)
+	}
+}
+
+// MaxChannelOccupancy returns the most signals ever found queued in the listening channel, including the one being received.
+// It helps to size the channel buffer, a value reaching the buffer size means signals might have been dropped.
+func (s *Handlers) MaxChannelOccupancy() int {
+	return int(s.maxOccupancy.Load())
+}
+
+// DroppedSignalEstimate returns how many times the listening channel was still full right after receiving a signal,
+// i.e. signals kept arriving faster than they were handled. It is always zero with a buffer of one signal or less,
+// see SetSignalBufferSize.
+// NOTE: os/signal drops signals silently once the channel is full, this is an estimate rather than an exact count.
+func (s *Handlers) DroppedSignalEstimate() uint64 {
+	return s.fullObservations.Load()
+}
+
+// Dispatch delivers sig to registered handlers as if it was received from the OS, it returns once dispatch is done.
+// It is meant for tests of registered handlers, the OS is bypassed and no real signal is sent. A termination signal
+// runs termination procedures then calls the exit func, see SetExit.
+// NOTE: it takes the same path as a received signal, so ignored signals, the termination debounce and dropping of
+// termination signals while terminating apply alike.
+func (s *Handlers) Dispatch(sig os.Signal) {
+	logAt(s.log, s.signalLogLevel(sig), "signal dispatched: ", sig)
+	s.handleSignal(sig)
+}
+
+// SignalCount returns how many times sig has been dispatched.
+func (s *Handlers) SignalCount(sig os.Signal) uint64 {
+	count, ok := s.signalCounts.Load(sig)
+	if !ok {
 		return 0
 	}
+	return count.(*atomic.Uint64).Load()
+}
 
-	var code = 0
-	for _, proc := range s.terminationProcedures {
-		s.log.Info(proc.message)
-		err := proc.fn(sig)
-		if err == nil {
-			continue
+func (s *Handlers) countSignal(sig os.Signal) {
+	count, ok := s.signalCounts.Load(sig)
+	if !ok {
+		count, _ = s.signalCounts.LoadOrStore(sig, new(atomic.Uint64))
+	}
+	count.(*atomic.Uint64).Add(1)
+}
+
+// classifySignal reports whether sig is ignored, and whether it is a termination signal, under a single lock.
+func (s *Handlers) classifySignal(sig os.Signal) (ignored, termination bool) {
+	s.globalLock.RLock()
+	defer s.globalLock.RUnlock()
+	if _, ignored = s.ignored[sig]; ignored {
+		return true, false
+	}
+	return false, s.isTerminationSignal(sig)
+}
+
+func (s *Handlers) handleSignal(target os.Signal) {
+	ignored, termination := s.classifySignal(target)
+	if ignored {
+		s.log.Debug("signal ignored: ", target)
+		return
+	}
+	// the bookkeeping takes no lock unless events are observed, so a minimal setup only takes the lock above
+	s.countSignal(target)
+	s.emit(Event{Kind: EventSignalReceived, Signal: target})
+	s.publish(target)
+	if !termination && !s.hasUserHandlers.Load() {
+		// only the termination handler is registered, nothing to dispatch
+		return
+	}
+	s.dispatchSignal(target)
+	s.purgeRemovedHandlers()
+}
+
+func (s *Handlers) dispatchSignal(target os.Signal) {
+	s.globalLock.RLock()
+	entries := s.collectHandlers(s.startupHandlers, target, nil)
+	entries = s.collectHandlers(s.handlers, target, entries)
+	s.globalLock.RUnlock()
+
+	// handlers are called without the lock so they may register or deregister handlers and procedures
+	if len(entries) == 0 {
+		s.log.Debug("no handler found for signal: ", target)
+	}
+	for _, entry := range entries {
+		s.callHandler(entry, target)
+	}
+}
+
+// HasHandler reports whether a handler registered by the user is called for sig, either registered to sig or to all
+// signals. The handling of termination signals by Handlers itself doesn't count, neither do ignored signals.
+func (s *Handlers) HasHandler(sig os.Signal) bool {
+	if s.isIgnored(sig) {
+		return false
+	}
+	s.globalLock.RLock()
+	defer s.globalLock.RUnlock()
+	entries := s.collectHandlers(s.startupHandlers, sig, nil)
+	for _, entry := range s.collectHandlers(s.handlers, sig, entries) {
+		if !entry.termination && !entry.removed.Load() {
+			return true
+		}
+	}
+	return false
+}
+
+// collectHandlers appends the handlers of target in registry to entries in calling order.
+func (s *Handlers) collectHandlers(registry map[os.Signal][]*handlerEntry, target os.Signal, entries []*handlerEntry) []*handlerEntry {
+	if !s.anySkipsTermination || !s.isTerminationSignal(target) {
+		for _, entry := range registry[anySignal] {
+			if !s.excludes(entry, target) {
+				entries = append(entries, entry)
+			}
 		}
-		s.log.Info("error while running termination procedure: ", err)
-		if code == 0 {
-			code = getCodeFromError(err, 1)
+	}
+
+	if !s.customMatcher {
+		// the default matcher is equivalent to comparing map keys
+		return append(entries, registry[target]...)
+	}
+	start := len(entries)
+	for sig, registered := range registry {
+		if sig != anySignal && s.matchSignal(target, sig) {
+			entries = append(entries, registered...)
 		}
 	}
-	s.log.Info("all termination procedures are done")
-	return code
+	// the map is ranged in random order, handlers of several matching signals are called in registered order anyway
+	matched := entries[start:]
+	sort.SliceStable(matched, func(i, j int) bool {
+		return matched[i].seq < matched[j].seq
+	})
+	return entries
 }
 
-func (s *Handlers) setExit(e func(int)) {
+// excludes reports whether target is excluded from the signals of entry registered to all signals.
+func (s *Handlers) excludes(entry *handlerEntry, target os.Signal) bool {
+	for _, sig := range entry.except {
+		if s.matchSignal(target, sig) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Handlers) isTerminationSignal(sig os.Signal) bool {
+	for _, termination := range s.terminationSignals {
+		if s.matchSignal(sig, termination) {
+			return true
+		}
+	}
+	return false
+}
+
+// SetAnySignalIncludesTermination sets whether handlers registered to all signals are called for termination signals.
+// NOTE: it is true by default, i.e. handlers registered without signals are called for every signal including termination ones.
+func (s *Handlers) SetAnySignalIncludesTermination(include bool) {
+	s.globalLock.Lock()
+	defer s.globalLock.Unlock()
+	s.anySkipsTermination = !include
+}
+
+// callHandler calls entry unless it is deregistered, a panic is recovered and logged so other handlers still run.
+func (s *Handlers) callHandler(entry *handlerEntry, sig os.Signal) {
+	if entry.removed.Load() {
+		return
+	}
+	defer func() {
+		if v := recover(); v != nil {
+			logWarn(s.log, "panic recovered in handler of signal: ", sig, ", ", v, "\n", string(debug.Stack()))
+		}
+	}()
+	entry.fn(sig)
+}
+
+// stopListen cancels the context returned by StartListenContext, if any, after recording sig in it.
+func (s *Handlers) stopListen(sig os.Signal) {
+	s.globalLock.RLock()
+	cancelListen, received := s.listenCancel, s.listenSignal
+	s.globalLock.RUnlock()
+	if received != nil {
+		received.set(sig)
+	}
+	if cancelListen != nil {
+		cancelListen()
+	}
+}
+
+func (s *Handlers) runTerminationProcedures(sig os.Signal) ShutdownReport {
+	ctx, cancel := s.shutdownContext()
+	defer cancel()
+	return s.runTerminationProceduresWithin(ctx, sig)
+}
+
+// runTerminationProceduresWithin runs termination procedures until ctx is done.
+func (s *Handlers) runTerminationProceduresWithin(ctx context.Context, sig os.Signal) ShutdownReport {
+	s.globalLock.RLock()
+	procedures, captureStacks, tracer := s.terminationProcedures, s.captureStacks, s.tracer
+	parallel, strategy, defaultCode := s.parallelTermination, s.exitCodeStrategy, s.defaultErrorCode
+	extractCode := s.exitCodeExtractor
+	s.globalLock.RUnlock()
+	report := ShutdownReport{Signal: sig, PID: os.Getpid(), Uptime: time.Since(s.startTime)}
+	messages := s.getMessages()
+	s.logMessage(messages.ShutdownStart, ", pid: ", report.PID, ", uptime: ", report.Uptime)
+	s.emit(Event{Kind: EventShutdownStarted, Signal: sig})
+	defer s.emit(Event{Kind: EventShutdownDone, Signal: sig})
+	span, end := startSpan(tracer, shutdownSpanName)
+	defer end()
+	defer func() { setShutdownStatus(span, report) }()
+	s.waitTracked(ctx)
+	if len(procedures) == 0 {
+		s.logMessage(messages.NothingToDo)
+		return report
+	}
+
+	escalation := s.newEscalationRun()
+	defer escalation.stop()
+
+	ordered := escalation.order(orderProcedures(procedures))
+	var timedOut, aborted = false, false
+	for start := 0; start < len(ordered); {
+		end := start + 1
+		for parallel && end < len(ordered) && ordered[end].stage == ordered[start].stage {
+			end++
+		}
+		group := ordered[start:end]
+		start = end
+
+		runs := make([]procedureRun, len(group))
+		var wg sync.WaitGroup
+		for i, proc := range group {
+			if aborted || (timedOut && !proc.ignoreGlobalDeadline) || escalation.skip(proc.stage) {
+				runs[i] = skipProcedure(proc, tracer)
+				continue
+			}
+			stageCtx := escalation.context(ctx, proc.stage)
+			if len(group) == 1 {
+				runs[i] = s.runTracedProcedure(ctx, stageCtx, proc, sig, captureStacks, tracer)
+				continue
+			}
+			wg.Add(1)
+			go func(i int, proc terminationProcedure) {
+				defer wg.Done()
+				runs[i] = s.runTracedProcedure(ctx, stageCtx, proc, sig, captureStacks, tracer)
+			}(i, proc)
+		}
+		wg.Wait()
+
+		for _, run := range runs {
+			result := run.result
+			timedOut = timedOut || (run.deadlineFired && !run.stageExpired)
+			if run.stageExpired {
+				escalation.expire(result.Stage)
+			} else if run.deadlineFired {
+				report.TimedOutStage = result.Stage
+			}
+			report.Procedures = append(report.Procedures, result)
+			s.emit(Event{Kind: EventProcedureDone, Signal: sig, Procedure: result})
+			if requested, clamped := clampedCode(result.Err); clamped {
+				logWarn(s.log, "exit code out of range 1-255 is clamped: ", requested)
+			}
+			if result.Err != nil {
+				report.ExitCode = strategy.fold(report.ExitCode, procedureExitCode(result.Err, defaultCode, extractCode))
+			}
+			if !aborted && errors.Is(result.Err, ErrAbortTermination) {
+				aborted = true
+				logError(s.log, "termination aborted by procedure: ", result.Message, ", remaining ones are skipped")
+			}
+		}
+	}
+	s.inFlight.Store("")
+	s.logMessage(messages.AllProceduresDone)
+	return report
+}
+
+// procedureRun is a termination procedure which ran or has been skipped.
+type procedureRun struct {
+	result ProcedureResult
+	// deadlineFired reports whether the shutdown deadline or the budget of the stage fired while running.
+	deadlineFired bool
+	// stageExpired reports whether it is the budget of the stage which fired.
+	stageExpired bool
+}
+
+// skipProcedure reports proc as skipped.
+func skipProcedure(proc terminationProcedure, tracer Tracer) procedureRun {
+	result := ProcedureResult{Message: proc.message, Stage: proc.stage, Status: Skipped}
+	span, end := startSpan(tracer, proc.message)
+	span.SetStatus(result.Status, nil)
+	end()
+	return procedureRun{result: result}
+}
+
+// runTracedProcedure runs proc within stageCtx derived from the shutdown ctx, then logs and reports its result.
+func (s *Handlers) runTracedProcedure(
+	ctx, stageCtx context.Context, proc terminationProcedure, sig os.Signal, captureStacks bool, tracer Tracer,
+) procedureRun {
+	result := ProcedureResult{Message: proc.message, Stage: proc.stage}
+	span, end := startSpan(tracer, proc.message)
+	defer end()
+
+	s.log.Info(proc.message)
+	s.inFlight.Store(proc.message)
+	start := time.Now()
+	outcome := runProcedure(stageCtx, proc, sig, captureStacks)
+	result.Duration = time.Since(start)
+	result.Err, result.Stack = outcome.err, outcome.stack
+	result.Panicked, result.Panic = outcome.panicked, outcome.panicValue
+	stageExpired := outcome.deadlineFired && ctx.Err() == nil
+	switch {
+	case result.Err == nil:
+		result.Status = Succeeded
+	case stageExpired:
+		result.Status = TimedOut
+		logWarn(s.log, "budget of stage ", proc.stage, " elapsed while running termination procedure: ", proc.message)
+	case outcome.deadlineFired:
+		result.Status = TimedOut
+		logWarn(s.log, "shutdown timed out while running termination procedure: ", proc.message)
+	case result.Err == ErrProcedureTimeout:
+		result.Status = TimedOut
+		logWarn(s.log, "termination procedure timed out: ", proc.message)
+	case result.Panicked:
+		result.Status = Failed
+		logError(s.log, "termination procedure panicked: ", proc.message, ", ", result.Panic)
+	default:
+		result.Status = Failed
+		logError(s.log, "error while running termination procedure: ", proc.message, ", ", result.Err)
+	}
+	span.SetStatus(result.Status, result.Err)
+	if result.Panicked {
+		logError(s.log, "stack of the panicked termination procedure: ", proc.message, "\n", result.Stack)
+	} else if result.Stack != "" {
+		logWarn(s.log, "stack of the timed out termination procedure: ", proc.message, "\n", result.Stack)
+	}
+	return procedureRun{result: result, deadlineFired: outcome.deadlineFired, stageExpired: stageExpired}
+}
+
+// procedureOutcome is the outcome of runProcedure.
+type procedureOutcome struct {
+	err error
+	// deadlineFired reports whether the shutdown deadline fired while running.
+	deadlineFired bool
+	// stack is the stack of the abandoned goroutine running the procedure, only captured if asked, or of the panic.
+	stack      string
+	panicked   bool
+	panicValue interface{}
+}
+
+// runProcedure runs proc, it gives up waiting once shutdown is done or the timeout of proc fires.
+func runProcedure(shutdown context.Context, proc terminationProcedure, sig os.Signal, captureStack bool) procedureOutcome {
+	if proc.ignoreGlobalDeadline {
+		shutdown = context.WithoutCancel(shutdown)
+	}
+	ctx := shutdown
+	if proc.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(shutdown, proc.timeout)
+		defer cancel()
+	}
+	if ctx.Done() == nil {
+		return callProcedure(ctx, proc, sig)
+	}
+
+	done := make(chan procedureOutcome, 1)
+	goroutine := make(chan uint64, 1)
+	go func() {
+		if captureStack {
+			goroutine <- currentGoroutineID()
+		}
+		done <- callProcedure(ctx, proc, sig)
+	}()
+
+	select {
+	case outcome := <-done:
+		return outcome
+	case <-ctx.Done():
+	}
+
+	outcome := procedureOutcome{err: ErrProcedureTimeout}
+	if shutdown.Err() != nil {
+		outcome.err, outcome.deadlineFired = ErrShutdownTimeout, true
+	}
+	if captureStack {
+		select {
+		case id := <-goroutine:
+			outcome.stack = goroutineStack(id)
+		default:
+		}
+	}
+	return outcome
+}
+
+// callProcedure calls proc, a panic is recovered and turned into an error by panicError.
+func callProcedure(ctx context.Context, proc terminationProcedure, sig os.Signal) (outcome procedureOutcome) {
+	defer func() {
+		if v := recover(); v != nil {
+			outcome = procedureOutcome{
+				err:        panicError(v),
+				stack:      string(debug.Stack()),
+				panicked:   true,
+				panicValue: v,
+			}
+		}
+	}()
+	return procedureOutcome{err: proc.fn(ctx, sig)}
+}
+
+// orderProcedures groups procedures by stage, in the order stages are first registered, then sorts them by priority
+// within each stage.
+func orderProcedures(procs []terminationProcedure) []terminationProcedure {
+	var stages []string
+	byStage := make(map[string][]terminationProcedure)
+	for _, proc := range procs {
+		if _, exists := byStage[proc.stage]; !exists {
+			stages = append(stages, proc.stage)
+		}
+		byStage[proc.stage] = append(byStage[proc.stage], proc)
+	}
+
+	ordered := make([]terminationProcedure, 0, len(procs))
+	for _, stage := range stages {
+		staged := byStage[stage]
+		sort.SliceStable(staged, func(i, j int) bool {
+			return staged[i].priority < staged[j].priority
+		})
+		ordered = append(ordered, staged...)
+	}
+	return ordered
+}
+
+func defaultSignalMatcher(got, registered os.Signal) bool {
+	gotNum, ok1 := got.(syscall.Signal)
+	registeredNum, ok2 := registered.(syscall.Signal)
+	if ok1 && ok2 {
+		return gotNum == registeredNum
+	}
+	return got == registered
+}
+
+// SetSignalMatcher sets the function used to match received signals against registered ones.
+// NOTE: by default signals are compared by their syscall.Signal number and fall back to equality.
+func (s *Handlers) SetSignalMatcher(m SignalMatcher) {
+	s.globalLock.Lock()
+	defer s.globalLock.Unlock()
+	s.customMatcher = m != nil
+	if m == nil {
+		m = defaultSignalMatcher
+	}
+	s.matchSignal = m
+}
+
+// SetExit sets the function called with the exit code once a termination is done, os.Exit by default.
+// It lets the exit be intercepted, e.g. by a supervisor.
+// NOTE: if e does not exit, the process keeps running after termination procedures, and e must be set before listening.
+func (s *Handlers) SetExit(e func(int)) {
+	s.globalLock.Lock()
+	defer s.globalLock.Unlock()
 	s.exit = e
 }
 
-// SetLogger sets the logger to be used.
+func (s *Handlers) getExit() func(int) {
+	s.globalLock.RLock()
+	defer s.globalLock.RUnlock()
+	return s.exit
+}
+
+// SetLogger sets the logger to be used, a nil logger discards all logs.
 func (s *Handlers) SetLogger(l Logger) {
 	s.globalLock.Lock()
 	defer s.globalLock.Unlock()
-	s.log = l
+	s.log = orNopLogger(l)
 }
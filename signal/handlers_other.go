@@ -0,0 +1,11 @@
+//go:build !unix && !windows
+
+package signal
+
+import (
+	"os"
+	"syscall"
+)
+
+// DefaultTerminationSignals is used when user doesn't provide their own.
+var DefaultTerminationSignals = []os.Signal{syscall.SIGTERM, syscall.SIGINT}
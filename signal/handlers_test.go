@@ -1,13 +1,21 @@
 package signal
 
 import (
+	"context"
+	"errors"
 	"io"
 	"os"
 	"os/signal"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"syscall"
 	"testing"
 	"time"
 
+	"github.com/flexi-cache/pkg/testutil"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -16,7 +24,7 @@ func _newHandlers(exit func(int)) *Handlers {
 	if exit == nil {
 		exit = func(int) {}
 	}
-	handlers.setExit(exit)
+	handlers.SetExit(exit)
 	return handlers
 }
 
@@ -87,14 +95,18 @@ func TestHandlersHandlesDefaultTerminationSignals(t *testing.T) {
 func TestHandlersListensForExpectedSignals(t *testing.T) {
 	handlers := _newHandlers(nil)
 
+	var lock sync.Mutex
 	var called = ""
-
-	handlers.RegisterSignalHandler(func(sig os.Signal) {
+	record := func(sig os.Signal) {
+		lock.Lock()
+		defer lock.Unlock()
 		called += sig.String()
-	}, syscall.SIGUSR1)
+	}
+
+	handlers.RegisterSignalHandler(record, syscall.SIGUSR1)
 
 	handlers.RegisterTerminationProcedure(func(sig os.Signal) error {
-		called += sig.String()
+		record(sig)
 		return nil
 	}, "")
 
@@ -102,10 +114,233 @@ func TestHandlersListensForExpectedSignals(t *testing.T) {
 	defer stop()
 
 	for _, sig := range append(DefaultTerminationSignals, syscall.SIGUSR1) {
-		syscall.Kill(os.Getpid(), sig.(syscall.Signal))
-		time.Sleep(time.Millisecond * 50)
-		assert.Contains(t, called, sig.String())
+		testutil.SendSignalAndWait(t, sig.(syscall.Signal), time.Second, func() bool {
+			lock.Lock()
+			defer lock.Unlock()
+			return strings.Contains(called, sig.String())
+		})
+	}
+}
+
+func TestHandlersRegisterSignalHandlerWithState(t *testing.T) {
+	t.Parallel()
+	type pool struct{ name string }
+	handlers := _newHandlers(nil)
+	handlers.SetLogger(nopTestLogger{})
+	state := &pool{name: "db"}
+
+	var called []string
+	var got interface{}
+	handlers.RegisterSignalHandler(func(os.Signal) { called = append(called, "first") }, syscall.SIGUSR1)
+	deregister := handlers.RegisterSignalHandlerWithState(func(sig os.Signal, s interface{}) {
+		called = append(called, "state")
+		got = s
+	}, state, syscall.SIGUSR1)
+	handlers.RegisterSignalHandler(func(os.Signal) { called = append(called, "all") })
+	handlers.RegisterSignalHandler(func(os.Signal) { called = append(called, "last") }, syscall.SIGUSR1)
+
+	handlers.handleSignal(syscall.SIGUSR1)
+	assert.Equal(t, []string{"all", "first", "state", "last"}, called)
+	assert.Same(t, state, got)
+
+	deregister()
+	called = nil
+	handlers.handleSignal(syscall.SIGUSR1)
+	assert.Equal(t, []string{"all", "first", "last"}, called)
+}
+
+func TestHandlersSetTerminationSignalsReplacesThem(t *testing.T) {
+	t.Parallel()
+	exits := 0
+	handlers := NewHandlers(syscall.SIGTERM)
+	handlers.SetExit(func(int) { exits++ })
+	handlers.SetLogger(nopTestLogger{})
+	handled := 0
+	handlers.RegisterSignalHandler(func(os.Signal) { handled++ }, syscall.SIGTERM)
+
+	handlers.SetTerminationSignals(syscall.SIGINT)
+	handlers.SetTerminationSignals(syscall.SIGINT)
+	handlers.handleSignal(syscall.SIGTERM)
+	assert.Equal(t, 0, exits)
+	assert.Equal(t, 1, handled)
+	handlers.handleSignal(syscall.SIGINT)
+	assert.Equal(t, 1, exits)
+	assert.Equal(t, []os.Signal{syscall.SIGINT}, handlers.terminationSignals)
+	assert.Len(t, handlers.handlers[syscall.SIGINT], 1)
+}
+
+func TestHandlersStartedFollowsListening(t *testing.T) {
+	t.Parallel()
+	handlers := newHandlers(WithSignalSource(make(chan os.Signal)), WithLogger(nopTestLogger{}))
+	assert.False(t, handlers.Started())
+
+	first := handlers.StartListen()
+	assert.True(t, handlers.Started())
+	second := handlers.StartListen()
+	first()
+	first()
+	assert.True(t, handlers.Started())
+	second()
+	assert.False(t, handlers.Started())
+
+	selective := _newHandlers(nil)
+	selective.SetLogger(nopTestLogger{})
+	stop := selective.StartListenSelective()
+	assert.True(t, selective.Started())
+	stop()
+	assert.False(t, selective.Started())
+}
+
+func TestHandlersStartListenOnceRejectsSecondStart(t *testing.T) {
+	t.Parallel()
+	source := make(chan os.Signal, 1)
+	handlers := newHandlers(WithSignalSource(source), WithLogger(nopTestLogger{}))
+	entered := make(chan os.Signal, 2)
+	release := make(chan struct{})
+	handlers.RegisterSignalHandler(func(sig os.Signal) {
+		entered <- sig
+		<-release
+	})
+	stop, err := handlers.StartListenOnce()
+	assert.NoError(t, err)
+	again, err := handlers.StartListenOnce()
+	assert.ErrorIs(t, err, ErrAlreadyListening)
+	assert.Nil(t, again)
+
+	source <- syscall.SIGUSR1
+	assert.Equal(t, syscall.SIGUSR1, <-entered)
+	source <- syscall.SIGUSR2
+	select {
+	case sig := <-entered:
+		t.Fatal("signal handled by a second listener: ", sig)
+	case <-time.After(time.Millisecond * 20):
+	}
+	close(release)
+	assert.Equal(t, syscall.SIGUSR2, <-entered)
+
+	stop()
+	stop, err = handlers.StartListenOnce()
+	assert.NoError(t, err)
+	stop()
+
+	stop = handlers.StartListen()
+	_, err = handlers.StartListenOnce()
+	assert.ErrorIs(t, err, ErrAlreadyListening)
+	stop()
+	stop, err = handlers.StartListenOnce()
+	assert.NoError(t, err)
+	assert.True(t, handlers.Started())
+	stop()
+	assert.False(t, handlers.Started())
+}
+
+// NOTE: it must not run in parallel since the signals are sent to the whole test process.
+func TestHandlersDispatchMatchesReceivedSignal(t *testing.T) {
+	run := func(deliver func(*Handlers, syscall.Signal)) []string {
+		handlers := _newHandlers(nil)
+		handlers.SetLogger(nopTestLogger{})
+		handlers.Ignore(syscall.SIGUSR2)
+		var lock sync.Mutex
+		var calls []string
+		record := func(name string) HandlerFunc {
+			return func(sig os.Signal) {
+				lock.Lock()
+				defer lock.Unlock()
+				calls = append(calls, name+" "+sig.String())
+			}
+		}
+		handlers.RegisterSignalHandler(record("any"))
+		handlers.RegisterSignalHandler(record("usr1"), syscall.SIGUSR1)
+		handlers.RegisterSignalHandler(record("usr2"), syscall.SIGUSR2)
+		stop := handlers.StartListenSelective()
+		defer stop()
+
+		deliver(handlers, syscall.SIGUSR2)
+		deliver(handlers, syscall.SIGUSR1)
+		assert.Eventually(t, func() bool { return handlers.SignalCount(syscall.SIGUSR1) == 1 }, time.Second, time.Millisecond)
+		time.Sleep(time.Millisecond * 20)
+		lock.Lock()
+		defer lock.Unlock()
+		return append(calls, "usr2 count "+strconv.FormatUint(handlers.SignalCount(syscall.SIGUSR2), 10))
+	}
+
+	dispatched := run(func(handlers *Handlers, sig syscall.Signal) { handlers.Dispatch(sig) })
+	received := run(func(_ *Handlers, sig syscall.Signal) { testutil.SendSignal(t, sig) })
+	assert.Equal(t, []string{"any user defined signal 1", "usr1 user defined signal 1", "usr2 count 0"}, dispatched)
+	assert.Equal(t, dispatched, received)
+}
+
+// NOTE: it must not run in parallel since the signals are sent to the whole test process.
+func TestHandlersSignalBufferKeepsBurst(t *testing.T) {
+	handlers := _newHandlers(nil)
+	handlers.SetLogger(nopTestLogger{})
+	handlers.SetSignalBufferSize(4)
+	burst := []os.Signal{syscall.SIGUSR1, syscall.SIGUSR2, syscall.SIGHUP, syscall.SIGWINCH}
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	received := make(chan os.Signal, len(burst))
+	var once sync.Once
+	handlers.RegisterSignalHandler(func(sig os.Signal) {
+		once.Do(func() {
+			close(entered)
+			<-release
+		})
+		received <- sig
+	}, burst...)
+	stop := handlers.StartListenSelective()
+	defer stop()
+
+	assert.NoError(t, syscall.Kill(os.Getpid(), syscall.SIGUSR1))
+	<-entered
+	for _, sig := range burst[1:] {
+		assert.NoError(t, syscall.Kill(os.Getpid(), sig.(syscall.Signal)))
+	}
+	time.Sleep(time.Millisecond * 50)
+	close(release)
+
+	var handled []os.Signal
+	for range burst {
+		select {
+		case sig := <-received:
+			handled = append(handled, sig)
+		case <-time.After(time.Second):
+			t.Fatal("signals dropped, handled: ", handled)
+		}
 	}
+	assert.ElementsMatch(t, burst, handled)
+}
+
+func TestHandlersSetSignalBufferSizeAtLeastOne(t *testing.T) {
+	t.Parallel()
+	handlers := _newHandlers(nil)
+	assert.Equal(t, 1, handlers.signalBufferSize)
+	handlers.SetSignalBufferSize(0)
+	assert.Equal(t, 1, handlers.signalBufferSize)
+}
+
+func TestHandlersIgnoredSignalsReachNoHandler(t *testing.T) {
+	t.Parallel()
+	exited := false
+	handlers := _newHandlers(func(int) { exited = true })
+	handlers.SetLogger(nopTestLogger{})
+	called := ""
+	handlers.RegisterSignalHandler(func(sig os.Signal) { called += "any " })
+	handlers.RegisterSignalHandler(func(sig os.Signal) { called += "pipe " }, syscall.SIGPIPE)
+	handlers.RegisterTerminationProcedure(func(os.Signal) error {
+		called += "procedure "
+		return nil
+	}, "")
+	handlers.Ignore(syscall.SIGPIPE, syscall.SIGTERM)
+
+	handlers.handleSignal(syscall.SIGPIPE)
+	handlers.handleSignal(syscall.SIGTERM)
+	assert.Empty(t, called)
+	assert.False(t, exited)
+	assert.Zero(t, handlers.SignalCount(syscall.SIGTERM))
+
+	handlers.handleSignal(syscall.SIGINT)
+	assert.Equal(t, "any procedure ", called)
+	assert.True(t, exited)
 }
 
 func TestHandlersReturnsZeroWhileNoError(t *testing.T) {
@@ -153,16 +388,62 @@ func TestHandlersReturnsGivenCodeWhileErrorSpecified(t *testing.T) {
 	assert.Equal(t, 42, ret)
 }
 
+func TestHandlersRegisterSignalHandlerExceptSkipsExcluded(t *testing.T) {
+	t.Parallel()
+	handlers := _newHandlers(nil)
+	handlers.SetLogger(nopTestLogger{})
+	var handled []os.Signal
+	deregister := handlers.RegisterSignalHandlerExcept(func(sig os.Signal) {
+		handled = append(handled, sig)
+	}, syscall.SIGTERM, syscall.SIGUSR1)
+	handlers.RegisterSignalHandler(func(os.Signal) {}, syscall.SIGUSR1)
+
+	handlers.handleSignal(syscall.SIGUSR1)
+	handlers.handleSignal(syscall.SIGTERM)
+	handlers.handleSignal(syscall.SIGUSR2)
+	handlers.handleSignal(syscall.SIGINT)
+	assert.Equal(t, []os.Signal{syscall.SIGUSR2, syscall.SIGINT}, handled)
+
+	deregister()
+	handlers.handleSignal(syscall.SIGUSR2)
+	assert.Len(t, handled, 2)
+}
+
+func TestHandlersSetExitReceivesSelectedCode(t *testing.T) {
+	t.Parallel()
+	handlers := NewHandlers()
+	handlers.SetLogger(nopTestLogger{})
+	codes := make(chan int, 1)
+	handlers.SetExit(func(code int) { codes <- code })
+	handlers.RegisterTerminationProcedure(func(os.Signal) error { return WrapErrorWithCode(io.EOF, 7) }, "")
+
+	handlers.Dispatch(syscall.SIGTERM)
+	assert.Equal(t, 7, <-codes)
+}
+
 func TestHandlersSetLoggerOK(t *testing.T) {
 	t.Parallel()
 	handlers := _newHandlers(nil)
 	oldLogger := handlers.log
 	handlers.SetLogger(nil)
 	newLogger := handlers.log
-	assert.Equal(t, nil, newLogger)
+	assert.Equal(t, nopLogger{}, newLogger)
 	assert.NotEqual(t, newLogger, oldLogger)
 }
 
+func TestHandlersNilLoggerDoesNotPanic(t *testing.T) {
+	t.Parallel()
+	ret := -1
+	handlers := _newHandlers(func(code int) { ret = code })
+	handlers.SetLogger(nil)
+	handlers.RegisterTerminationProcedure(func(os.Signal) error { return errors.New("failed") }, "failing")
+	assert.NotPanics(t, func() {
+		handlers.handleSignal(syscall.SIGUSR1)
+		handlers.handleSignal(syscall.SIGTERM)
+	})
+	assert.Equal(t, 1, ret)
+}
+
 func TestSignalStopDoesNotClosesTheChan(t *testing.T) {
 	t.Parallel()
 	c := make(chan os.Signal, 1)
@@ -177,3 +458,654 @@ func TestAnySignalContainsWarning(t *testing.T) {
 	assert.Contains(t, anySignal.String(),
 		"if you see this other than the source code, there is something wrong")
 }
+
+type customSignal string
+
+func (customSignal) Signal() {}
+
+func (c customSignal) String() string { return string(c) }
+
+func TestHandlersCustomSignalMatcherOK(t *testing.T) {
+	t.Parallel()
+	handlers := _newHandlers(nil)
+	called := 0
+	handlers.RegisterSignalHandler(func(os.Signal) { called++ }, customSignal("reload"))
+
+	handlers.handleSignal(customSignal("reload-with-info"))
+	assert.Equal(t, 0, called)
+
+	handlers.SetSignalMatcher(func(got, registered os.Signal) bool {
+		return got == registered || (got == customSignal("reload-with-info") && registered == customSignal("reload"))
+	})
+	handlers.handleSignal(customSignal("reload-with-info"))
+	assert.Equal(t, 1, called)
+}
+
+func TestHandlersCustomMatcherCallsHandlersInRegisteredOrder(t *testing.T) {
+	t.Parallel()
+	handlers := _newHandlers(nil)
+	handlers.SetSignalMatcher(func(got, registered os.Signal) bool { return true })
+	order := ""
+	for _, name := range []string{"a", "b", "c", "d", "e", "f"} {
+		name := name
+		handlers.RegisterSignalHandler(func(os.Signal) { order += name }, customSignal(name))
+	}
+	handlers.RegisterSignalHandler(func(os.Signal) { order += "a" }, customSignal("a"))
+
+	for i := 0; i < 20; i++ {
+		order = ""
+		handlers.handleSignal(customSignal("any"))
+		assert.Equal(t, "abcdefa", order)
+	}
+}
+
+func TestDefaultSignalMatcherComparesNumbers(t *testing.T) {
+	assert.True(t, defaultSignalMatcher(syscall.SIGUSR1, syscall.SIGUSR1))
+	assert.False(t, defaultSignalMatcher(syscall.SIGUSR1, syscall.SIGUSR2))
+	assert.True(t, defaultSignalMatcher(customSignal("a"), customSignal("a")))
+	assert.False(t, defaultSignalMatcher(customSignal("a"), syscall.SIGUSR1))
+}
+
+func TestHandlersRunsStagesInRegisteredOrder(t *testing.T) {
+	t.Parallel()
+	handlers := _newHandlers(nil)
+	order := ""
+	record := func(name string) TerminationFunc {
+		return NewTerminationFunc(func() { order += name + " " })
+	}
+
+	handlers.RegisterTerminationProcedureInStage(record("drain1"), "", "drain")
+	handlers.RegisterTerminationProcedureInStage(record("close1"), "", "close")
+	handlers.RegisterTerminationProcedureInStage(record("drain2"), "", "drain")
+	handlers.runTerminationProcedures(syscall.SIGTERM)
+	assert.Equal(t, "drain1 drain2 close1 ", order)
+}
+
+func TestHandlersSortsByPriorityOnlyWithinStages(t *testing.T) {
+	t.Parallel()
+	handlers := _newHandlers(nil)
+	order := ""
+	record := func(name string) TerminationFunc {
+		return NewTerminationFunc(func() { order += name + " " })
+	}
+
+	handlers.RegisterTerminationProcedureInStage(record("a"), "", "first")
+	handlers.RegisterTerminationProcedureWithOptions(record("b"), ProcedureOptions{Stage: "second", Priority: -1})
+	handlers.RegisterTerminationProcedureWithOptions(record("c"), ProcedureOptions{Stage: "first", Priority: -1})
+	handlers.runTerminationProcedures(syscall.SIGTERM)
+	assert.Equal(t, "c a b ", order)
+}
+
+func TestHandlersReportsTimedOutStage(t *testing.T) {
+	t.Parallel()
+	var code = -1
+	handlers := _newHandlers(func(c int) { code = c })
+	block := make(chan struct{})
+	defer close(block)
+
+	handlers.RegisterTerminationProcedureInStage(NewTerminationFunc(func() {}), "stop accepting", "drain")
+	handlers.RegisterTerminationProcedureInStage(func(os.Signal) error {
+		return io.EOF
+	}, "flush", "drain")
+	handlers.RegisterTerminationProcedureInStage(func(os.Signal) error {
+		<-block
+		return nil
+	}, "wait for requests", "drain")
+	handlers.RegisterTerminationProcedureInStage(NewTerminationFunc(func() {}), "close db", "close")
+	handlers.SetShutdownTimeout(time.Millisecond * 50)
+	handlers.handleSignal(syscall.SIGTERM)
+
+	report := handlers.LastShutdownReport()
+	assert.Equal(t, 1, code)
+	assert.Equal(t, 1, report.ExitCode)
+	assert.Equal(t, "drain", report.TimedOutStage)
+	var statuses []ProcedureStatus
+	for _, result := range report.Procedures {
+		statuses = append(statuses, result.Status)
+	}
+	assert.Equal(t, []ProcedureStatus{Succeeded, Failed, TimedOut, Skipped}, statuses)
+	assert.Equal(t, ErrShutdownTimeout, report.Procedures[2].Err)
+	assert.Equal(t, "close db", report.Procedures[3].Message)
+}
+
+func TestHandlersSelfDeregisteringHandlerRunsOnce(t *testing.T) {
+	t.Parallel()
+	handlers := _newHandlers(nil)
+	called := ""
+	handlers.RegisterSignalHandlerSelf(func(sig os.Signal, deregister func()) {
+		called += "self "
+		deregister()
+	}, syscall.SIGUSR1, syscall.SIGUSR2)
+	handlers.RegisterSignalHandler(func(os.Signal) {
+		called += "other "
+	}, syscall.SIGUSR1)
+
+	handlers.handleSignal(syscall.SIGUSR1)
+	handlers.handleSignal(syscall.SIGUSR1)
+	handlers.handleSignal(syscall.SIGUSR2)
+	assert.Equal(t, "self other other ", called)
+	assert.Len(t, handlers.handlers[syscall.SIGUSR1], 1)
+	assert.Len(t, handlers.handlers[syscall.SIGUSR2], 0)
+}
+
+func TestHandlersCallbacksMayRegisterWithoutDeadlock(t *testing.T) {
+	t.Parallel()
+	exitCalled := false
+	handlers := _newHandlers(func(int) { exitCalled = true })
+	handlers.SetLogger(nopTestLogger{})
+	called := ""
+	handlers.RegisterSignalHandler(func(os.Signal) {
+		called += "outer "
+		handlers.RegisterSignalHandler(func(os.Signal) { called += "inner " }, syscall.SIGUSR1)
+	}, syscall.SIGUSR1)
+	handlers.RegisterTerminationProcedure(func(os.Signal) error {
+		handlers.RegisterTerminationProcedure(func(os.Signal) error { return nil }, "late")
+		handlers.RegisterSignalHandler(func(os.Signal) {}, syscall.SIGUSR2)
+		return nil
+	}, "")
+
+	testutil.AssertReturnsWithin(t, time.Second, func() {
+		handlers.handleSignal(syscall.SIGUSR1)
+		handlers.handleSignal(syscall.SIGUSR1)
+		handlers.handleSignal(syscall.SIGTERM)
+	})
+	assert.Equal(t, "outer outer inner ", called)
+	assert.True(t, exitCalled)
+}
+
+func TestHandlersRecoversPanickingHandler(t *testing.T) {
+	t.Parallel()
+	logger := &recordingLogger{}
+	exitCalled := false
+	handlers := _newHandlers(func(int) { exitCalled = true })
+	handlers.SetLogger(logger)
+	handlers.SetAnySignalIncludesTermination(true)
+	handlers.RegisterSignalHandler(func(os.Signal) { panic("boom") })
+	called := false
+	handlers.RegisterSignalHandler(func(os.Signal) { called = true })
+	procedureCalled := false
+	handlers.RegisterTerminationProcedure(func(os.Signal) error {
+		procedureCalled = true
+		return nil
+	}, "")
+
+	handlers.handleSignal(syscall.SIGUSR1)
+	assert.True(t, called)
+	assert.True(t, logger.contains("panic recovered in handler of signal: user defined signal 1, boom"))
+
+	handlers.handleSignal(syscall.SIGTERM)
+	assert.True(t, procedureCalled)
+	assert.True(t, exitCalled)
+}
+
+func TestHandlersOnceHandlerRunsOnceForAllItsSignals(t *testing.T) {
+	t.Parallel()
+	handlers := _newHandlers(nil)
+	called := ""
+	handlers.RegisterSignalHandlerOnce(func(sig os.Signal) {
+		called += "once "
+	}, syscall.SIGUSR1, syscall.SIGUSR2)
+	handlers.RegisterSignalHandler(func(os.Signal) {
+		called += "other "
+	}, syscall.SIGUSR1)
+
+	handlers.handleSignal(syscall.SIGUSR1)
+	handlers.handleSignal(syscall.SIGUSR1)
+	handlers.handleSignal(syscall.SIGUSR2)
+	assert.Equal(t, "once other other ", called)
+	assert.Len(t, handlers.handlers[syscall.SIGUSR1], 1)
+	assert.Len(t, handlers.handlers[syscall.SIGUSR2], 0)
+}
+
+func TestHandlersDeregistersHandlerFromAllItsSignals(t *testing.T) {
+	t.Parallel()
+	handlers := _newHandlers(nil)
+	called := ""
+	deregister := handlers.RegisterSignalHandler(func(sig os.Signal) {
+		called += "removed "
+	}, syscall.SIGUSR1, syscall.SIGUSR2)
+	handlers.RegisterSignalHandler(func(os.Signal) {
+		called += "kept "
+	}, syscall.SIGUSR1)
+
+	handlers.handleSignal(syscall.SIGUSR2)
+	deregister()
+	deregister()
+	assert.Len(t, handlers.handlers[syscall.SIGUSR1], 1)
+	assert.Len(t, handlers.handlers[syscall.SIGUSR2], 0)
+	handlers.handleSignal(syscall.SIGUSR1)
+	handlers.handleSignal(syscall.SIGUSR2)
+	assert.Equal(t, "removed kept ", called)
+}
+
+func TestHandlersDeregisterIsSafeWhileDispatching(t *testing.T) {
+	t.Parallel()
+	handlers := _newHandlers(nil)
+	var called atomic.Int32
+	var deregisters []func()
+	for i := 0; i < 50; i++ {
+		deregisters = append(deregisters, handlers.RegisterSignalHandler(func(os.Signal) { called.Add(1) }, syscall.SIGUSR1))
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 50; i++ {
+			handlers.handleSignal(syscall.SIGUSR1)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for _, deregister := range deregisters {
+			deregister()
+		}
+	}()
+	wg.Wait()
+
+	before := called.Load()
+	handlers.handleSignal(syscall.SIGUSR1)
+	assert.Equal(t, before, called.Load())
+	assert.Len(t, handlers.handlers[syscall.SIGUSR1], 0)
+}
+
+func TestHandlersReplaceTerminationProceduresIsAtomic(t *testing.T) {
+	t.Parallel()
+	handlers := _newHandlers(nil)
+	handlers.SetLogger(nopTestLogger{})
+	var lock sync.Mutex
+	var runs []string
+	procs := func(name string) []NamedProcedure {
+		var named []NamedProcedure
+		for i := 0; i < 3; i++ {
+			named = append(named, NamedProcedure{Name: name, Fn: func(os.Signal) error {
+				lock.Lock()
+				runs = append(runs, name)
+				lock.Unlock()
+				return nil
+			}})
+		}
+		return named
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(2)
+		name := string(rune('a' + i))
+		go func() {
+			defer wg.Done()
+			handlers.ReplaceTerminationProcedures(procs(name))
+		}()
+		go func() {
+			defer wg.Done()
+			report := handlers.runTerminationProcedures(syscall.SIGTERM)
+			for _, result := range report.Procedures {
+				assert.Equal(t, report.Procedures[0].Message, result.Message)
+			}
+		}()
+	}
+	wg.Wait()
+
+	handlers.ReplaceTerminationProcedures(procs("final"))
+	runs = nil
+	handlers.runTerminationProcedures(syscall.SIGTERM)
+	assert.Equal(t, []string{"final", "final", "final"}, runs)
+}
+
+type nopTestLogger struct{}
+
+func (nopTestLogger) Info(...interface{}) {}
+
+func (nopTestLogger) Debug(...interface{}) {}
+
+func TestHandlersTracksChannelOccupancyUnderLoad(t *testing.T) {
+	t.Parallel()
+	for _, capacity := range []int{1, 4} {
+		source := make(chan os.Signal, capacity)
+		handlers := newHandlers(WithSignalSource(source), WithLogger(nopTestLogger{}))
+		handled := make(chan struct{}, 20)
+		handlers.RegisterSignalHandler(func(os.Signal) {
+			time.Sleep(time.Millisecond)
+			handled <- struct{}{}
+		}, syscall.SIGUSR2)
+
+		stop := handlers.StartListen()
+		// the sender blocks on the full channel, so it is full again right after each receive
+		for i := 0; i < 20; i++ {
+			source <- syscall.SIGUSR2
+		}
+		for i := 0; i < 20; i++ {
+			<-handled
+		}
+		stop()
+
+		assert.Equal(t, capacity, handlers.MaxChannelOccupancy())
+		if capacity == 1 {
+			assert.Equal(t, uint64(0), handlers.DroppedSignalEstimate())
+		} else {
+			assert.Greater(t, handlers.DroppedSignalEstimate(), uint64(0))
+		}
+	}
+}
+
+func TestHandlersObserveOccupancyKeepsHighWaterMark(t *testing.T) {
+	t.Parallel()
+	handlers := _newHandlers(nil)
+	handlers.observeOccupancy(2, 8)
+	handlers.observeOccupancy(0, 8)
+	assert.Equal(t, 3, handlers.MaxChannelOccupancy())
+	assert.Equal(t, uint64(0), handlers.DroppedSignalEstimate())
+
+	handlers.observeOccupancy(7, 8)
+	assert.Equal(t, 8, handlers.MaxChannelOccupancy())
+	assert.Equal(t, uint64(0), handlers.DroppedSignalEstimate())
+	handlers.observeOccupancy(8, 8)
+	assert.Equal(t, 8, handlers.MaxChannelOccupancy())
+	assert.Equal(t, uint64(1), handlers.DroppedSignalEstimate())
+
+	handlers.observeOccupancy(1, 1)
+	handlers.observeOccupancy(0, 0)
+	assert.Equal(t, uint64(1), handlers.DroppedSignalEstimate())
+}
+
+func TestHandlersStartupSignalHandlerRunsOnlyBeforeStartupComplete(t *testing.T) {
+	t.Parallel()
+	handlers := _newHandlers(nil)
+	called := ""
+	handlers.RegisterSignalHandler(func(os.Signal) { called += "steady " }, syscall.SIGUSR1)
+	handlers.RegisterStartupSignalHandler(func(os.Signal) { called += "startup " }, syscall.SIGUSR1)
+	handlers.RegisterStartupSignalHandler(func(os.Signal) { called += "startup-any " })
+
+	handlers.handleSignal(syscall.SIGUSR1)
+	assert.Equal(t, "startup-any startup steady ", called)
+
+	called = ""
+	handlers.MarkStartupComplete()
+	handlers.RegisterStartupSignalHandler(func(os.Signal) { called += "late " }, syscall.SIGUSR1)
+	handlers.handleSignal(syscall.SIGUSR1)
+	assert.Equal(t, "steady ", called)
+}
+
+func TestHandlersStartupSignalHandlerRunsBeforeTermination(t *testing.T) {
+	t.Parallel()
+	called := ""
+	handlers := _newHandlers(func(int) { called += "exit " })
+	handlers.RegisterTerminationProcedure(NewTerminationFunc(func() { called += "drain " }), "")
+	handlers.RegisterStartupSignalHandler(func(os.Signal) { called += "abort " }, syscall.SIGTERM)
+
+	handlers.handleSignal(syscall.SIGTERM)
+	assert.Equal(t, "abort drain exit ", called)
+}
+
+func TestHandlersAnySignalIncludesTerminationByDefault(t *testing.T) {
+	t.Parallel()
+	called := ""
+	handlers := _newHandlers(func(int) { called += "exit " })
+	handlers.RegisterSignalHandler(func(os.Signal) { called += "any " })
+
+	handlers.handleSignal(syscall.SIGTERM)
+	assert.Equal(t, "any exit ", called)
+}
+
+func TestHandlersAnySignalExcludesTerminationWhenDisabled(t *testing.T) {
+	t.Parallel()
+	called := ""
+	handlers := _newHandlers(func(int) { called += "exit " })
+	handlers.RegisterSignalHandler(func(os.Signal) { called += "any " })
+	handlers.SetAnySignalIncludesTermination(false)
+
+	handlers.handleSignal(syscall.SIGTERM)
+	assert.Equal(t, "exit ", called)
+	handlers.handleSignal(syscall.SIGUSR1)
+	assert.Equal(t, "exit any ", called)
+}
+
+type testContextKey struct{}
+
+func TestHandlersPassesListenContextToTerminationProcedures(t *testing.T) {
+	t.Parallel()
+	source := make(chan os.Signal)
+	exited := make(chan int, 1)
+	handlers := newHandlers(WithSignalSource(source), WithLogger(nopTestLogger{}), WithShutdownTimeout(time.Millisecond*50))
+	handlers.SetExit(func(code int) { exited <- code })
+	parent := context.WithValue(context.Background(), testContextKey{}, "deploy-1")
+	listenCtx, stop := handlers.StartListenContext(parent)
+	defer stop()
+
+	type observed struct {
+		listenDone, hasDeadline bool
+		value                   interface{}
+	}
+	observations := make(chan observed, 1)
+	handlers.RegisterTerminationProcedureCtx(func(ctx context.Context, sig os.Signal) error {
+		o := observed{listenDone: listenCtx.Err() != nil, value: ctx.Value(testContextKey{})}
+		_, o.hasDeadline = ctx.Deadline()
+		observations <- o
+		<-ctx.Done()
+		return ctx.Err()
+	}, "waiting for the deadline")
+
+	source <- syscall.SIGTERM
+	assert.Equal(t, 1, <-exited)
+	o := <-observations
+	assert.True(t, o.listenDone)
+	assert.True(t, o.hasDeadline)
+	assert.Equal(t, "deploy-1", o.value)
+	assert.Equal(t, TimedOut, handlers.LastShutdownReport().Procedures[0].Status)
+}
+
+func TestHandlersStopsListeningWhenListenParentDone(t *testing.T) {
+	t.Parallel()
+	source := make(chan os.Signal)
+	handlers := newHandlers(WithSignalSource(source), WithLogger(nopTestLogger{}))
+	parent, cancel := context.WithCancel(context.Background())
+	listenCtx, stop := handlers.StartListenContext(parent)
+	defer stop()
+
+	cancel()
+	<-listenCtx.Done()
+	time.Sleep(time.Millisecond * 10)
+	select {
+	case source <- syscall.SIGUSR1:
+		t.Fatal("signal received after parent is done")
+	case <-time.After(time.Millisecond * 20):
+	}
+}
+
+func TestHandlersHasHandler(t *testing.T) {
+	t.Parallel()
+	handlers := _newHandlers(nil)
+	handlers.RegisterTerminationProcedure(func(os.Signal) error { return nil }, "")
+	assert.False(t, handlers.HasHandler(syscall.SIGTERM))
+
+	deregister := handlers.RegisterSignalHandler(func(os.Signal) {}, syscall.SIGUSR1)
+	assert.True(t, handlers.HasHandler(syscall.SIGUSR1))
+	assert.False(t, handlers.HasHandler(syscall.SIGUSR2))
+	deregister()
+	assert.False(t, handlers.HasHandler(syscall.SIGUSR1))
+
+	deregister = handlers.RegisterSignalHandler(func(os.Signal) {})
+	assert.True(t, handlers.HasHandler(syscall.SIGUSR2))
+	handlers.Ignore(syscall.SIGUSR2)
+	assert.False(t, handlers.HasHandler(syscall.SIGUSR2))
+	deregister()
+	assert.False(t, handlers.HasHandler(syscall.SIGHUP))
+}
+
+// NOTE: it must not run in parallel since it counts the goroutines of the whole test process.
+func TestHandlersListenContextDoesNotLeakGoroutines(t *testing.T) {
+	source := make(chan os.Signal)
+	handlers := newHandlers(WithSignalSource(source), WithLogger(nopTestLogger{}))
+	before := runtime.NumGoroutine()
+	for i := 0; i < 50; i++ {
+		_, stop := handlers.StartListenContext(context.Background())
+		stop()
+	}
+	assert.Eventually(t, func() bool {
+		return runtime.NumGoroutine() <= before+5
+	}, time.Second, time.Millisecond*10)
+}
+
+func TestHandlersMinimalSetupStillHandlesTermination(t *testing.T) {
+	t.Parallel()
+	exitCalled := 0
+	handlers := _newHandlers(func(int) { exitCalled++ })
+	assert.False(t, handlers.hasUserHandlers.Load())
+
+	handlers.handleSignal(syscall.SIGUSR1)
+	assert.Equal(t, 0, exitCalled)
+	handlers.handleSignal(syscall.SIGTERM)
+	assert.Equal(t, 1, exitCalled)
+}
+
+func BenchmarkHandlersHandleSignalMinimal(b *testing.B) {
+	handlers := _newHandlers(nil)
+	handlers.SetLogger(nopTestLogger{})
+	for i := 0; i < b.N; i++ {
+		handlers.handleSignal(syscall.SIGUSR1)
+	}
+}
+
+func BenchmarkHandlersHandleSignalWithHandler(b *testing.B) {
+	handlers := _newHandlers(nil)
+	handlers.SetLogger(nopTestLogger{})
+	handlers.RegisterSignalHandler(func(os.Signal) {}, syscall.SIGUSR2)
+	for i := 0; i < b.N; i++ {
+		handlers.handleSignal(syscall.SIGUSR1)
+	}
+}
+
+func TestHandlersProcedureIgnoringGlobalDeadlineCompletes(t *testing.T) {
+	t.Parallel()
+	handlers := _newHandlers(nil)
+	handlers.SetShutdownTimeout(time.Millisecond * 20)
+	block := make(chan struct{})
+	defer close(block)
+	committed := false
+
+	handlers.RegisterTerminationProcedure(func(os.Signal) error {
+		<-block
+		return nil
+	}, "hanging")
+	handlers.RegisterTerminationProcedure(NewTerminationFunc(func() {}), "skipped")
+	handlers.RegisterTerminationProcedureWithOptions(NewTerminationFunc(func() {
+		time.Sleep(time.Millisecond * 40)
+		committed = true
+	}), ProcedureOptions{Message: "commit", IgnoreGlobalDeadline: true})
+
+	report := handlers.runTerminationProcedures(syscall.SIGTERM)
+	assert.True(t, committed)
+	var statuses []ProcedureStatus
+	for _, result := range report.Procedures {
+		statuses = append(statuses, result.Status)
+	}
+	assert.Equal(t, []ProcedureStatus{TimedOut, Skipped, Succeeded}, statuses)
+	assert.Equal(t, 1, report.ExitCode)
+}
+
+func TestHandlersProcedureIgnoringGlobalDeadlineKeepsItsOwnTimeout(t *testing.T) {
+	t.Parallel()
+	handlers := _newHandlers(nil)
+	handlers.SetShutdownTimeout(time.Millisecond * 10)
+	block := make(chan struct{})
+	defer close(block)
+
+	handlers.RegisterTerminationProcedureWithOptions(func(os.Signal) error {
+		<-block
+		return nil
+	}, ProcedureOptions{Message: "commit", IgnoreGlobalDeadline: true, Timeout: time.Millisecond * 40})
+
+	start := time.Now()
+	report := handlers.runTerminationProcedures(syscall.SIGTERM)
+	assert.GreaterOrEqual(t, time.Since(start), time.Millisecond*40)
+	assert.Equal(t, ErrProcedureTimeout, report.Procedures[0].Err)
+	assert.Equal(t, "", report.TimedOutStage)
+}
+
+func TestHandlersDispatchRunsHandlersAndCounts(t *testing.T) {
+	t.Parallel()
+	exitCode := -1
+	handlers := _newHandlers(func(code int) { exitCode = code })
+	handlers.SetLogger(nopTestLogger{})
+	called := 0
+	handlers.RegisterSignalHandler(func(os.Signal) { called++ }, syscall.SIGUSR1)
+
+	handlers.Dispatch(syscall.SIGUSR1)
+	handlers.Dispatch(syscall.SIGUSR1)
+	assert.Equal(t, 2, called)
+	assert.Equal(t, uint64(2), handlers.SignalCount(syscall.SIGUSR1))
+	assert.Equal(t, uint64(0), handlers.SignalCount(syscall.SIGUSR2))
+
+	handlers.Dispatch(syscall.SIGTERM)
+	assert.Equal(t, 0, exitCode)
+	assert.Equal(t, uint64(1), handlers.SignalCount(syscall.SIGTERM))
+}
+
+func TestHandlersRunsTerminationProceduresInParallel(t *testing.T) {
+	t.Parallel()
+	handlers := _newHandlers(nil)
+	handlers.SetLogger(nopTestLogger{})
+	handlers.SetParallelTermination(true)
+	var running atomic.Int32
+	started := make(chan struct{})
+	wait := func(delay time.Duration, err error) TerminationFunc {
+		return func(os.Signal) error {
+			if running.Add(1) == 3 {
+				close(started)
+			}
+			<-started
+			time.Sleep(delay)
+			return err
+		}
+	}
+	handlers.RegisterTerminationProcedure(wait(0, nil), "close redis")
+	handlers.RegisterTerminationProcedure(wait(time.Millisecond*20, WrapErrorWithCode(io.EOF, 3)), "flush metrics")
+	handlers.RegisterTerminationProcedure(wait(0, WrapErrorWithCode(io.EOF, 4)), "drain queue")
+
+	var report ShutdownReport
+	testutil.AssertReturnsWithin(t, time.Second, func() {
+		report = handlers.runTerminationProcedures(syscall.SIGTERM)
+	})
+	assert.Equal(t, 3, report.ExitCode)
+	if assert.Len(t, report.Procedures, 3) {
+		assert.Equal(t, "close redis", report.Procedures[0].Message)
+		assert.Equal(t, Failed, report.Procedures[1].Status)
+		assert.Equal(t, Failed, report.Procedures[2].Status)
+	}
+}
+
+func TestHandlersResetKeepsTerminationHandling(t *testing.T) {
+	t.Parallel()
+	exitCalled := 0
+	handlers := _newHandlers(func(int) { exitCalled++ })
+	handlers.SetLogger(nopTestLogger{})
+	called := false
+	handlers.RegisterSignalHandler(func(os.Signal) { called = true })
+	handlers.RegisterTerminationProcedure(func(os.Signal) error {
+		called = true
+		return nil
+	}, "")
+
+	handlers.Reset()
+	assert.False(t, handlers.hasUserHandlers.Load())
+	handlers.handleSignal(syscall.SIGUSR1)
+	handlers.handleSignal(syscall.SIGTERM)
+	assert.False(t, called)
+	assert.Equal(t, 1, exitCalled)
+	assert.Equal(t, DefaultTerminationSignals, handlers.terminationSignals)
+}
+
+func TestHandlersClearDropsTerminationHandling(t *testing.T) {
+	t.Parallel()
+	exitCalled := 0
+	handlers := _newHandlers(func(int) { exitCalled++ })
+	handlers.RegisterSignalHandler(func(os.Signal) { t.Fatal("handler called after clear") })
+
+	handlers.Clear()
+	handlers.handleSignal(syscall.SIGTERM)
+	assert.Equal(t, 0, exitCalled)
+
+	handlers.Reset()
+	handlers.handleSignal(syscall.SIGTERM)
+	assert.Equal(t, 1, exitCalled)
+}
@@ -0,0 +1,9 @@
+//go:build windows
+
+package signal
+
+import "os"
+
+// DefaultTerminationSignals is used when user doesn't provide their own.
+// NOTE: only interrupt is delivered on Windows, i.e. Ctrl+C or Ctrl+Break in the console.
+var DefaultTerminationSignals = []os.Signal{os.Interrupt}
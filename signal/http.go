@@ -0,0 +1,72 @@
+package signal
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"time"
+)
+
+// RegisterHTTPServer registers a termination procedure shutting srv down gracefully within timeout, zero means no limit
+// other than the shutdown timeout and deadline. Failing to shut down in time exits with ProcedureTimeoutExitCode, otherwise with the
+// default error code, see SetDefaultErrorCode.
+func (s *Handlers) RegisterHTTPServer(srv *http.Server, timeout time.Duration) ProcedureID {
+	return s.RegisterTerminationProcedureCtx(func(ctx context.Context, _ os.Signal) error {
+		if timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+			defer cancel()
+		}
+		err := srv.Shutdown(ctx)
+		if errors.Is(err, context.DeadlineExceeded) {
+			return WrapErrorWithCode(err, ProcedureTimeoutExitCode)
+		}
+		return err
+	}, "shutting down http server")
+}
+
+// ServeGraceful runs srv until a termination signal is received, then shuts it down gracefully and returns the exit code.
+//
+// srv.Shutdown is given the shutdown timeout (see WithShutdownTimeout) as deadline.
+// If srv stops serving with an unexpected error, the termination procedures are run and a non-zero code is returned.
+// The process is never exited by ServeGraceful, srv must not be closed or shut down by others.
+func ServeGraceful(srv *http.Server, opts ...Option) int {
+	codes := make(chan int, 1)
+	handlers := newHandlers(opts...)
+	handlers.SetExit(func(code int) {
+		select {
+		case codes <- code:
+		default:
+		}
+	})
+	handlers.RegisterTerminationProcedureCtx(func(ctx context.Context, _ os.Signal) error {
+		return srv.Shutdown(ctx)
+	}, "shutting down http server")
+
+	stop := handlers.StartListen()
+	defer stop()
+
+	served := make(chan error, 1)
+	go func() {
+		served <- srv.ListenAndServe()
+	}()
+
+	select {
+	case code := <-codes:
+		return code
+	case err := <-served:
+		if err == http.ErrServerClosed {
+			return <-codes
+		}
+		handlers.log.Info("http server stopped unexpectedly: ", err)
+		report, ok := handlers.shutdown(SignalProgrammatic)
+		if !ok {
+			return <-codes
+		}
+		if report.ExitCode == 0 {
+			return 1
+		}
+		return report.ExitCode
+	}
+}
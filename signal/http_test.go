@@ -0,0 +1,81 @@
+package signal
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServeGracefulShutsDownOnTerminationSignal(t *testing.T) {
+	t.Parallel()
+	source := make(chan os.Signal, 1)
+	srv := &http.Server{Addr: "127.0.0.1:0", Handler: http.NotFoundHandler()}
+	codes := make(chan int, 1)
+	go func() {
+		codes <- ServeGraceful(srv,
+			WithSignalSource(source),
+			WithLogger(nopTestLogger{}),
+			WithShutdownTimeout(time.Second))
+	}()
+
+	time.Sleep(time.Millisecond * 50)
+	source <- syscall.SIGTERM
+	select {
+	case code := <-codes:
+		assert.Equal(t, 0, code)
+	case <-time.After(time.Second * 2):
+		t.Fatal("ServeGraceful did not return")
+	}
+	assert.Equal(t, http.ErrServerClosed, srv.ListenAndServe())
+}
+
+func TestServeGracefulReturnsNonZeroWhenServeFails(t *testing.T) {
+	t.Parallel()
+	occupied := httptest.NewServer(http.NotFoundHandler())
+	defer occupied.Close()
+
+	srv := &http.Server{Addr: occupied.Listener.Addr().String()}
+	code := ServeGraceful(srv, WithSignalSource(make(chan os.Signal)), WithLogger(nopTestLogger{}))
+	assert.Equal(t, 1, code)
+}
+
+func TestHandlersRegisterHTTPServerShutsDown(t *testing.T) {
+	t.Parallel()
+	srv := httptest.NewServer(http.NotFoundHandler())
+	defer srv.Close()
+	ret := -1
+	handlers := _newHandlers(func(code int) { ret = code })
+	handlers.SetLogger(nopTestLogger{})
+	handlers.RegisterHTTPServer(srv.Config, time.Second)
+
+	handlers.handleSignal(syscall.SIGTERM)
+	assert.Equal(t, 0, ret)
+	_, err := http.Get(srv.URL)
+	assert.Error(t, err)
+}
+
+func TestHandlersRegisterHTTPServerTimesOut(t *testing.T) {
+	t.Parallel()
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		close(entered)
+		<-release
+	}))
+	defer srv.Close()
+	go http.Get(srv.URL)
+	<-entered
+	defer close(release)
+
+	ret := -1
+	handlers := _newHandlers(func(code int) { ret = code })
+	handlers.SetLogger(nopTestLogger{})
+	handlers.RegisterHTTPServer(srv.Config, time.Millisecond*20)
+	handlers.handleSignal(syscall.SIGTERM)
+	assert.Equal(t, ProcedureTimeoutExitCode, ret)
+}
@@ -0,0 +1,47 @@
+//go:build unix
+
+package signal
+
+import (
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandlersSelectsTerminationAndRegisteredSignals(t *testing.T) {
+	t.Parallel()
+	handlers := _newHandlers(nil)
+	handlers.RegisterSignalHandler(func(os.Signal) {}, syscall.SIGUSR1, syscall.SIGTERM)
+	handlers.RegisterSignalHandler(func(os.Signal) {})
+	handlers.RegisterStartupSignalHandler(func(os.Signal) {}, syscall.SIGHUP)
+	handlers.Ignore(syscall.SIGPIPE, syscall.SIGINT)
+	assert.ElementsMatch(t, []os.Signal{syscall.SIGTERM, syscall.SIGINT, syscall.SIGUSR1, syscall.SIGHUP, syscall.SIGPIPE}, handlers.selectedSignals())
+}
+
+// NOTE: it must not run in parallel since the signals are sent to the whole test process.
+func TestHandlersListenSelectiveIgnoresUnregisteredSignals(t *testing.T) {
+	handlers := _newHandlers(nil)
+	handlers.SetLogger(nopTestLogger{})
+	received := make(chan os.Signal, 4)
+	handlers.RegisterSignalHandler(func(sig os.Signal) { received <- sig })
+	handlers.RegisterSignalHandler(func(os.Signal) {}, syscall.SIGUSR1)
+	stop := handlers.StartListenSelective()
+	defer stop()
+
+	assert.NoError(t, syscall.Kill(os.Getpid(), syscall.SIGWINCH))
+	assert.NoError(t, syscall.Kill(os.Getpid(), syscall.SIGUSR1))
+	select {
+	case sig := <-received:
+		assert.Equal(t, syscall.SIGUSR1, sig)
+	case <-time.After(time.Second):
+		t.Fatal("registered signal not received")
+	}
+	select {
+	case sig := <-received:
+		t.Fatal("unregistered signal received: ", sig)
+	case <-time.After(time.Millisecond * 20):
+	}
+}
@@ -8,6 +8,63 @@ type Logger interface {
 	Debug(...interface{})
 }
 
+// ErrorLogger is a Logger which is also able to log errors, failures are logged via Error if the logger implements it,
+// via Info otherwise.
+type ErrorLogger interface {
+	Logger
+	Error(...interface{})
+}
+
+// WarnLogger is a Logger which is also able to log warnings, events which are not failures but worth attention, e.g.
+// timeouts or dropped signals, are logged via Warn if the logger implements it, via Info otherwise.
+type WarnLogger interface {
+	Logger
+	Warn(...interface{})
+}
+
+// Level is the severity of a log line.
+type Level int
+
+const (
+	// LevelDebug logs via Logger.Debug.
+	LevelDebug Level = iota
+	// LevelInfo logs via Logger.Info.
+	LevelInfo
+	// LevelWarn logs via WarnLogger.Warn, or Logger.Info if the logger doesn't implement WarnLogger.
+	LevelWarn
+	// LevelError logs via ErrorLogger.Error, or Logger.Info if the logger doesn't implement ErrorLogger.
+	LevelError
+)
+
+func logAt(l Logger, level Level, args ...interface{}) {
+	switch level {
+	case LevelDebug:
+		l.Debug(args...)
+	case LevelWarn:
+		logWarn(l, args...)
+	case LevelError:
+		logError(l, args...)
+	default:
+		l.Info(args...)
+	}
+}
+
+func logError(l Logger, args ...interface{}) {
+	if el, ok := l.(ErrorLogger); ok {
+		el.Error(args...)
+		return
+	}
+	l.Info(args...)
+}
+
+func logWarn(l Logger, args ...interface{}) {
+	if wl, ok := l.(WarnLogger); ok {
+		wl.Warn(args...)
+		return
+	}
+	l.Info(args...)
+}
+
 type stdLogger struct{}
 
 func (stdLogger) Debug(...interface{}) {}
@@ -15,3 +72,29 @@ func (stdLogger) Debug(...interface{}) {}
 func (stdLogger) Info(args ...interface{}) {
 	log.Println(args...)
 }
+
+func (stdLogger) Warn(args ...interface{}) {
+	log.Println(args...)
+}
+
+func (stdLogger) Error(args ...interface{}) {
+	log.Println(args...)
+}
+
+// nopLogger discards everything, it stands in for a nil Logger.
+type nopLogger struct{}
+
+func (nopLogger) Debug(...interface{}) {}
+
+func (nopLogger) Info(...interface{}) {}
+
+func (nopLogger) Warn(...interface{}) {}
+
+func (nopLogger) Error(...interface{}) {}
+
+func orNopLogger(l Logger) Logger {
+	if l == nil {
+		return nopLogger{}
+	}
+	return l
+}
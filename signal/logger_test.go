@@ -0,0 +1,167 @@
+package signal
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type recordingLogger struct {
+	lock  sync.Mutex
+	lines []string
+}
+
+func (r *recordingLogger) record(level string, args ...interface{}) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.lines = append(r.lines, level+": "+fmt.Sprint(args...))
+}
+
+func (r *recordingLogger) Info(args ...interface{}) { r.record("info", args...) }
+
+func (r *recordingLogger) Debug(args ...interface{}) { r.record("debug", args...) }
+
+func (r *recordingLogger) Warn(args ...interface{}) { r.record("warn", args...) }
+
+func (r *recordingLogger) Error(args ...interface{}) { r.record("error", args...) }
+
+func (r *recordingLogger) contains(line string) bool {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	for _, l := range r.lines {
+		if strings.Contains(l, line) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestHandlersLogsReceivedSignalAtConfiguredLevel(t *testing.T) {
+	t.Parallel()
+	logger := &recordingLogger{}
+	source := make(chan os.Signal)
+	handlers := newHandlers(WithSignalSource(source), WithLogger(logger))
+	handlers.SetSignalLogLevel(syscall.SIGUSR1, LevelDebug)
+	stop := handlers.StartListen()
+	defer stop()
+
+	source <- syscall.SIGUSR1
+	source <- syscall.SIGUSR2
+	time.Sleep(time.Millisecond * 20)
+	assert.True(t, logger.contains("debug: signal received: "+syscall.SIGUSR1.String()))
+	assert.False(t, logger.contains("info: signal received: "+syscall.SIGUSR1.String()))
+	assert.True(t, logger.contains("info: signal received: "+syscall.SIGUSR2.String()))
+}
+
+func TestHandlersLogsProcedureErrorsViaError(t *testing.T) {
+	t.Parallel()
+	logger := &recordingLogger{}
+	handlers := _newHandlers(nil)
+	handlers.SetLogger(logger)
+	handlers.RegisterTerminationProcedure(func(os.Signal) error { return io.EOF }, "flush")
+	handlers.handleSignal(syscall.SIGTERM)
+
+	assert.True(t, logger.contains("error: error while running termination procedure: flush, EOF"))
+	assert.False(t, logger.contains("info: error while running termination procedure"))
+}
+
+func TestLogErrorFallsBackToInfo(t *testing.T) {
+	logger := &infoOnlyLogger{}
+	logError(logger, "boom")
+	assert.Equal(t, []string{"boom"}, logger.lines)
+}
+
+func TestLogWarnFallsBackToInfo(t *testing.T) {
+	logger := &infoOnlyLogger{}
+	logWarn(logger, "slow")
+	logAt(logger, LevelWarn, "slower")
+	assert.Equal(t, []string{"slow", "slower"}, logger.lines)
+}
+
+func TestHandlersLogsTimedOutProcedureViaWarn(t *testing.T) {
+	t.Parallel()
+	logger := &recordingLogger{}
+	handlers := _newHandlers(nil)
+	handlers.SetLogger(logger)
+	block := make(chan struct{})
+	defer close(block)
+	handlers.RegisterTerminationProcedureWithTimeout(func(os.Signal) error {
+		<-block
+		return nil
+	}, "drain", time.Millisecond*10)
+	handlers.handleSignal(syscall.SIGTERM)
+
+	assert.True(t, logger.contains("warn: termination procedure timed out: drain"))
+}
+
+func TestHandlersLogsDroppedSignalsViaWarn(t *testing.T) {
+	t.Parallel()
+	logger := &recordingLogger{}
+	handlers := _newHandlers(nil)
+	handlers.SetLogger(logger)
+	handlers.SetTerminationDebounce(time.Minute)
+	handlers.handleSignal(syscall.SIGTERM)
+	handlers.handleSignal(syscall.SIGTERM)
+
+	assert.True(t, logger.contains("warn: termination signal debounced: terminated"))
+}
+
+func TestHandlersLogsRecoveredHandlerPanicViaWarn(t *testing.T) {
+	t.Parallel()
+	logger := &recordingLogger{}
+	handlers := _newHandlers(nil)
+	handlers.SetLogger(logger)
+	handlers.RegisterSignalHandler(func(os.Signal) { panic("boom") }, syscall.SIGUSR1)
+	handlers.handleSignal(syscall.SIGUSR1)
+
+	assert.True(t, logger.contains("warn: panic recovered in handler of signal: user defined signal 1, boom"))
+	assert.False(t, logger.contains("error: panic recovered"))
+}
+
+type infoOnlyLogger struct {
+	lines []string
+}
+
+func (l *infoOnlyLogger) Info(args ...interface{}) { l.lines = append(l.lines, fmt.Sprint(args...)) }
+
+func (l *infoOnlyLogger) Debug(...interface{}) {}
+
+func TestHandlersLogsNoHandlerFoundOnlyOnMiss(t *testing.T) {
+	t.Parallel()
+	logger := &recordingLogger{}
+	handlers := _newHandlers(nil)
+	handlers.SetLogger(logger)
+	handlers.RegisterSignalHandler(func(os.Signal) {}, syscall.SIGUSR1)
+	handlers.RegisterSignalHandler(func(os.Signal) {}, syscall.SIGUSR2, syscall.SIGHUP)
+
+	handlers.handleSignal(syscall.SIGUSR1)
+	assert.False(t, logger.contains("no handler found"))
+
+	handlers.handleSignal(syscall.SIGWINCH)
+	logger.lock.Lock()
+	defer logger.lock.Unlock()
+	assert.Equal(t, []string{"debug: no handler found for signal: window changed"}, logger.lines)
+}
+
+func TestHandlersLogsConfiguredMessages(t *testing.T) {
+	t.Parallel()
+	logger := &recordingLogger{}
+	handlers := _newHandlers(nil)
+	handlers.SetLogger(logger)
+	messages := DefaultMessages
+	messages.Goodbye, messages.NothingToDo = "see you", ""
+	handlers.SetMessages(messages)
+	handlers.handleSignal(syscall.SIGTERM)
+
+	assert.True(t, logger.contains("info: shutting down, pid: "))
+	assert.True(t, logger.contains("info: see you"))
+	assert.False(t, logger.contains("bye"))
+	assert.False(t, logger.contains("nothing to do"))
+}
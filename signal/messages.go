@@ -0,0 +1,42 @@
+package signal
+
+// Messages are the lifecycle lines logged by Handlers, an empty one is not logged.
+type Messages struct {
+	// ShutdownStart is logged with the pid and the uptime once termination procedures start.
+	ShutdownStart string
+	// NothingToDo is logged when there is no termination procedure to run.
+	NothingToDo string
+	// AllProceduresDone is logged once all termination procedures are done.
+	AllProceduresDone string
+	// Goodbye is logged right before exit.
+	Goodbye string
+}
+
+// DefaultMessages are the messages used unless SetMessages is called.
+var DefaultMessages = Messages{
+	ShutdownStart:     "shutting down",
+	NothingToDo:       "nothing to do before termination",
+	AllProceduresDone: "all termination procedures are done",
+	Goodbye:           "bye",
+}
+
+// SetMessages sets the lifecycle lines to be logged, see Messages.
+func (s *Handlers) SetMessages(m Messages) {
+	s.globalLock.Lock()
+	defer s.globalLock.Unlock()
+	s.messages = m
+}
+
+func (s *Handlers) getMessages() Messages {
+	s.globalLock.RLock()
+	defer s.globalLock.RUnlock()
+	return s.messages
+}
+
+// logMessage logs msg followed by args unless msg is empty.
+func (s *Handlers) logMessage(msg string, args ...interface{}) {
+	if msg == "" {
+		return
+	}
+	s.log.Info(append([]interface{}{msg}, args...)...)
+}
@@ -0,0 +1,40 @@
+package signal
+
+import "time"
+
+// Metrics observes how long shutdown takes, e.g. to adapt to Prometheus for SLOs.
+type Metrics interface {
+	// ObserveShutdown observes a whole shutdown and the exit code selected from its procedures.
+	ObserveShutdown(duration time.Duration, code int)
+	// ObserveProcedure observes a termination or final procedure which ran, err is nil if it succeeded.
+	ObserveProcedure(name string, d time.Duration, err error)
+}
+
+type nopMetrics struct{}
+
+func (nopMetrics) ObserveShutdown(time.Duration, int) {}
+
+func (nopMetrics) ObserveProcedure(string, time.Duration, error) {}
+
+// SetMetrics sets the sink observing shutdowns and their procedures, a nil sink disables it, which is the default.
+func (s *Handlers) SetMetrics(m Metrics) {
+	if m == nil {
+		m = nopMetrics{}
+	}
+	s.globalLock.Lock()
+	defer s.globalLock.Unlock()
+	s.metrics = m
+}
+
+// observeMetrics observes report of a shutdown which took d, skipped procedures are not observed.
+func (s *Handlers) observeMetrics(report ShutdownReport, d time.Duration) {
+	s.globalLock.RLock()
+	metrics := s.metrics
+	s.globalLock.RUnlock()
+	for _, result := range report.Procedures {
+		if result.Status != Skipped {
+			metrics.ObserveProcedure(result.Message, result.Duration, result.Err)
+		}
+	}
+	metrics.ObserveShutdown(d, report.ExitCode)
+}
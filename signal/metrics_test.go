@@ -0,0 +1,69 @@
+package signal
+
+import (
+	"io"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type procedureObservation struct {
+	name string
+	d    time.Duration
+	err  error
+}
+
+type recordingMetrics struct {
+	procedures []procedureObservation
+	shutdowns  []time.Duration
+	codes      []int
+}
+
+func (m *recordingMetrics) ObserveShutdown(d time.Duration, code int) {
+	m.shutdowns = append(m.shutdowns, d)
+	m.codes = append(m.codes, code)
+}
+
+func (m *recordingMetrics) ObserveProcedure(name string, d time.Duration, err error) {
+	m.procedures = append(m.procedures, procedureObservation{name: name, d: d, err: err})
+}
+
+func TestHandlersObservesShutdownMetrics(t *testing.T) {
+	t.Parallel()
+	metrics := &recordingMetrics{}
+	handlers := _newHandlers(nil)
+	handlers.SetLogger(nopTestLogger{})
+	handlers.SetMetrics(metrics)
+	handlers.RegisterTerminationProcedure(func(os.Signal) error {
+		time.Sleep(time.Millisecond * 10)
+		return nil
+	}, "slow")
+	handlers.RegisterTerminationProcedure(func(os.Signal) error { return WrapErrorWithCode(io.EOF, 3) }, "failing")
+	handlers.RegisterFinalProcedure(func(os.Signal) error { return nil }, "final")
+
+	handlers.handleSignal(syscall.SIGTERM)
+	if assert.Len(t, metrics.procedures, 3) {
+		assert.Equal(t, "slow", metrics.procedures[0].name)
+		assert.GreaterOrEqual(t, metrics.procedures[0].d, time.Millisecond*10)
+		assert.NoError(t, metrics.procedures[0].err)
+		assert.Equal(t, "failing", metrics.procedures[1].name)
+		assert.ErrorIs(t, metrics.procedures[1].err, io.EOF)
+		assert.Equal(t, "final", metrics.procedures[2].name)
+	}
+	if assert.Len(t, metrics.shutdowns, 1) {
+		assert.GreaterOrEqual(t, metrics.shutdowns[0], time.Millisecond*10)
+	}
+	assert.Equal(t, []int{3}, metrics.codes)
+}
+
+func TestHandlersSetMetricsNilIsNop(t *testing.T) {
+	t.Parallel()
+	handlers := _newHandlers(nil)
+	handlers.SetLogger(nopTestLogger{})
+	handlers.SetMetrics(nil)
+	handlers.RegisterTerminationProcedure(func(os.Signal) error { return nil }, "")
+	assert.NotPanics(t, func() { handlers.handleSignal(syscall.SIGTERM) })
+}
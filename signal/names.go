@@ -0,0 +1,37 @@
+package signal
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+)
+
+// ParseSignals parses symbolic signal names such as "SIGUSR1", the "SIG" prefix and letter case are optional.
+// It returns an error for names unknown on the current platform, e.g. "SIGUSR1" on windows.
+func ParseSignals(names ...string) ([]os.Signal, error) {
+	signals := make([]os.Signal, 0, len(names))
+	for _, name := range names {
+		normalized := strings.ToUpper(strings.TrimSpace(name))
+		if !strings.HasPrefix(normalized, "SIG") {
+			normalized = "SIG" + normalized
+		}
+		sig, exists := signalNames[normalized]
+		if !exists {
+			return nil, fmt.Errorf("unknown signal %q on %s", name, runtime.GOOS)
+		}
+		signals = append(signals, sig)
+	}
+	return signals, nil
+}
+
+// RegisterSignalHandlerByName registers handler like RegisterSignalHandler with signals parsed by ParseSignals.
+// Nothing is registered if any name is unknown.
+func (s *Handlers) RegisterSignalHandlerByName(handler HandlerFunc, names ...string) error {
+	signals, err := ParseSignals(names...)
+	if err != nil {
+		return err
+	}
+	s.RegisterSignalHandler(handler, signals...)
+	return nil
+}
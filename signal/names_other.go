@@ -0,0 +1,14 @@
+//go:build !unix && !windows
+
+package signal
+
+import (
+	"os"
+	"syscall"
+)
+
+var signalNames = map[string]os.Signal{
+	"SIGINT":  syscall.SIGINT,
+	"SIGKILL": syscall.SIGKILL,
+	"SIGTERM": syscall.SIGTERM,
+}
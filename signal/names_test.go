@@ -0,0 +1,42 @@
+package signal
+
+import (
+	"os"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSignalsOK(t *testing.T) {
+	signals, err := ParseSignals("SIGUSR1", "usr2", " SIGhup ")
+	assert.NoError(t, err)
+	assert.Equal(t, []os.Signal{syscall.SIGUSR1, syscall.SIGUSR2, syscall.SIGHUP}, signals)
+}
+
+func TestParseSignalsReturnsErrorForUnknownName(t *testing.T) {
+	signals, err := ParseSignals("SIGUSR1", "SIGNOPE")
+	assert.Nil(t, signals)
+	assert.Contains(t, err.Error(), `unknown signal "SIGNOPE"`)
+}
+
+func TestHandlersRegisterSignalHandlerByNameOK(t *testing.T) {
+	t.Parallel()
+	handlers := _newHandlers(nil)
+	called := 0
+	assert.NoError(t, handlers.RegisterSignalHandlerByName(func(os.Signal) { called++ }, "SIGUSR1", "SIGUSR2"))
+
+	handlers.handleSignal(syscall.SIGUSR1)
+	handlers.handleSignal(syscall.SIGUSR2)
+	assert.Equal(t, 2, called)
+}
+
+func TestHandlersRegisterSignalHandlerByNameRegistersNothingForUnknownName(t *testing.T) {
+	t.Parallel()
+	handlers := _newHandlers(nil)
+	called := 0
+	assert.Error(t, handlers.RegisterSignalHandlerByName(func(os.Signal) { called++ }, "SIGUSR1", "SIGNOPE"))
+
+	handlers.handleSignal(syscall.SIGUSR1)
+	assert.Equal(t, 0, called)
+}
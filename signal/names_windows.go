@@ -0,0 +1,23 @@
+//go:build windows
+
+package signal
+
+import (
+	"os"
+	"syscall"
+)
+
+// NOTE: SIGHUP and SIGQUIT are left out like in reloadSignal and quitSignal, they are never delivered on windows.
+var signalNames = map[string]os.Signal{
+	"SIGABRT": syscall.SIGABRT,
+	"SIGALRM": syscall.SIGALRM,
+	"SIGBUS":  syscall.SIGBUS,
+	"SIGFPE":  syscall.SIGFPE,
+	"SIGILL":  syscall.SIGILL,
+	"SIGINT":  syscall.SIGINT,
+	"SIGKILL": syscall.SIGKILL,
+	"SIGPIPE": syscall.SIGPIPE,
+	"SIGSEGV": syscall.SIGSEGV,
+	"SIGTERM": syscall.SIGTERM,
+	"SIGTRAP": syscall.SIGTRAP,
+}
@@ -0,0 +1,66 @@
+package signal
+
+import (
+	"os"
+	"time"
+)
+
+// Option configures Handlers while creating it.
+type Option func(*Handlers)
+
+// WithTerminationSignals sets the termination signals, DefaultTerminationSignals is used if none given.
+func WithTerminationSignals(signals ...os.Signal) Option {
+	return func(s *Handlers) {
+		if len(signals) > 0 {
+			s.terminationSignals = signals
+		}
+	}
+}
+
+// WithLogger sets the logger to be used.
+func WithLogger(l Logger) Option {
+	return func(s *Handlers) {
+		s.log = orNopLogger(l)
+	}
+}
+
+// WithShutdownTimeout sets the time limit of running all termination procedures, see SetShutdownTimeout.
+func WithShutdownTimeout(d time.Duration) Option {
+	return func(s *Handlers) {
+		s.shutdownTimeout = d
+	}
+}
+
+// WithShutdownDeadline sets the hard limit of a termination, see SetShutdownDeadline.
+func WithShutdownDeadline(d time.Duration) Option {
+	return func(s *Handlers) {
+		s.shutdownDeadline = d
+	}
+}
+
+// WithExit sets the function called with the exit code once a termination is done, see SetExit.
+// A nil function keeps os.Exit.
+func WithExit(e func(int)) Option {
+	return func(s *Handlers) {
+		if e != nil {
+			s.exit = e
+		}
+	}
+}
+
+// WithSignalSource makes StartListen receive signals from source instead of the OS.
+// It is mostly useful in tests, source is never closed by Handlers.
+func WithSignalSource(source <-chan os.Signal) Option {
+	return func(s *Handlers) {
+		s.signalSource = source
+	}
+}
+
+// WithoutDefaultTermination makes termination signals not handled by Handlers, so neither termination procedures
+// run nor the process exits on them, e.g. when embedded in a framework deciding when to exit.
+// NOTE: termination signals are still listened, so handlers registered to them are called.
+func WithoutDefaultTermination() Option {
+	return func(s *Handlers) {
+		s.noDefaultTermination = true
+	}
+}
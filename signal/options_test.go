@@ -0,0 +1,75 @@
+package signal
+
+import (
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandlersWithoutDefaultTermination(t *testing.T) {
+	t.Parallel()
+	exited, terminated, handled := false, false, false
+	handlers := NewHandlersWithOptions(WithoutDefaultTermination(), WithLogger(nopTestLogger{}))
+	handlers.SetExit(func(int) { exited = true })
+	handlers.RegisterTerminationProcedure(func(os.Signal) error {
+		terminated = true
+		return nil
+	}, "")
+	handlers.RegisterSignalHandler(func(os.Signal) { handled = true }, syscall.SIGTERM)
+
+	handlers.handleSignal(syscall.SIGTERM)
+	assert.False(t, exited)
+	assert.False(t, terminated)
+	assert.True(t, handled)
+	assert.Contains(t, handlers.selectedSignals(), os.Signal(syscall.SIGTERM))
+
+	handlers.Reset()
+	handlers.handleSignal(syscall.SIGTERM)
+	assert.False(t, exited)
+}
+
+func TestNewHandlersWithOptionsDefaultsToNewHandlers(t *testing.T) {
+	t.Parallel()
+	code := -1
+	handlers := NewHandlersWithOptions(WithLogger(nopTestLogger{}))
+	handlers.SetExit(func(c int) { code = c })
+	assert.Equal(t, DefaultTerminationSignals, handlers.terminationSignals)
+	handlers.handleSignal(syscall.SIGTERM)
+	assert.Equal(t, 0, code)
+}
+
+func TestNewHandlersWithOptionsAppliesOptions(t *testing.T) {
+	t.Parallel()
+	code := -1
+	logger := &recordingLogger{}
+	source := make(chan os.Signal)
+	handlers := NewHandlersWithOptions(
+		WithLogger(logger),
+		WithTerminationSignals(syscall.SIGUSR1),
+		WithExit(func(c int) { code = c }),
+		WithShutdownTimeout(time.Second),
+		WithShutdownDeadline(time.Minute),
+		WithSignalSource(source),
+	)
+	assert.Same(t, logger, handlers.log)
+	assert.Equal(t, []os.Signal{syscall.SIGUSR1}, handlers.terminationSignals)
+	assert.Equal(t, time.Second, handlers.shutdownTimeout)
+	assert.Equal(t, time.Minute, handlers.shutdownDeadline)
+	assert.Equal(t, (<-chan os.Signal)(source), handlers.signalSource)
+
+	handlers.handleSignal(syscall.SIGTERM)
+	assert.Equal(t, -1, code)
+	handlers.handleSignal(syscall.SIGUSR1)
+	assert.Equal(t, 0, code)
+}
+
+func TestOptionsKeepDefaultsOnEmptyValues(t *testing.T) {
+	t.Parallel()
+	handlers := NewHandlersWithOptions(WithLogger(nil), WithTerminationSignals(), WithExit(nil))
+	assert.Equal(t, nopLogger{}, handlers.log)
+	assert.Equal(t, DefaultTerminationSignals, handlers.terminationSignals)
+	assert.NotNil(t, handlers.exit)
+}
@@ -0,0 +1,80 @@
+package signal
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LoadPlan registers termination procedures described by the plan read from r, in the order they are listed.
+//
+// Each non-empty line of a plan names a procedure followed by optional key=value settings, e.g.
+//
+//	# name          settings
+//	stop-accepting  stage=drain priority=-10
+//	close-db        stage=close timeout=5s
+//
+// Supported settings are stage, priority and timeout (parsed by time.ParseDuration), lines starting with # are comments.
+// Every name must be bound to a TerminationFunc by bindings, nothing is registered if the plan is invalid.
+// The IDs of registered procedures are returned in the order they are listed.
+func (s *Handlers) LoadPlan(r io.Reader, bindings map[string]TerminationFunc) ([]ProcedureID, error) {
+	procs, err := parsePlan(r, bindings)
+	if err != nil {
+		return nil, err
+	}
+	return s.addTerminationProcedures(procs...), nil
+}
+
+func parsePlan(r io.Reader, bindings map[string]TerminationFunc) ([]terminationProcedure, error) {
+	var procs []terminationProcedure
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+
+		name := fields[0]
+		fn, bound := bindings[name]
+		if !bound || fn == nil {
+			return nil, fmt.Errorf("plan line %d: no termination func bound to %q", line, name)
+		}
+		proc := terminationProcedure{fn: fn.withContext(), message: name}
+		for _, setting := range fields[1:] {
+			if err := applyPlanSetting(&proc, setting); err != nil {
+				return nil, fmt.Errorf("plan line %d: %v", line, err)
+			}
+		}
+		procs = append(procs, proc)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return procs, nil
+}
+
+func applyPlanSetting(proc *terminationProcedure, setting string) error {
+	key, value, ok := strings.Cut(setting, "=")
+	if !ok {
+		return fmt.Errorf("setting %q is not in key=value form", setting)
+	}
+
+	var err error
+	switch key {
+	case "stage":
+		proc.stage = value
+	case "priority":
+		proc.priority, err = strconv.Atoi(value)
+	case "timeout":
+		proc.timeout, err = time.ParseDuration(value)
+	default:
+		return fmt.Errorf("unknown setting %q", key)
+	}
+	if err != nil {
+		return fmt.Errorf("invalid %s: %v", key, err)
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package signal
+
+import (
+	"os"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadPlanRegistersProceduresInPlanOrder(t *testing.T) {
+	t.Parallel()
+	handlers := _newHandlers(nil)
+	order := ""
+	record := func(name string) TerminationFunc {
+		return NewTerminationFunc(func() { order += name + " " })
+	}
+	plan := `
+# shutdown plan
+close-db        stage=close timeout=1s
+stop-accepting  stage=drain priority=-1
+flush-metrics   stage=drain
+`
+	ids, err := handlers.LoadPlan(strings.NewReader(plan), map[string]TerminationFunc{
+		"stop-accepting": record("stop"),
+		"flush-metrics":  record("flush"),
+		"close-db":       record("close"),
+	})
+	assert.NoError(t, err)
+	assert.Len(t, handlers.terminationProcedures, 3)
+	assert.Equal(t, time.Second, handlers.terminationProcedures[0].timeout)
+	assert.Equal(t, -1, handlers.terminationProcedures[1].priority)
+	if assert.Len(t, ids, 3) {
+		assert.Equal(t, handlers.terminationProcedures[2].id, ids[2])
+	}
+
+	handlers.runTerminationProcedures(syscall.SIGTERM)
+	// stages run in plan order, priorities only order procedures within the drain stage
+	assert.Equal(t, "close stop flush ", order)
+
+	assert.True(t, handlers.RemoveTerminationProcedure(ids[1]))
+	order = ""
+	handlers.runTerminationProcedures(syscall.SIGTERM)
+	assert.Equal(t, "close flush ", order)
+}
+
+func TestLoadPlanReturnsErrorForUnboundName(t *testing.T) {
+	t.Parallel()
+	handlers := _newHandlers(nil)
+	ids, err := handlers.LoadPlan(strings.NewReader("close-db\nunknown stage=x\n"), map[string]TerminationFunc{
+		"close-db": func(os.Signal) error { return nil },
+	})
+	assert.EqualError(t, err, `plan line 2: no termination func bound to "unknown"`)
+	assert.Nil(t, ids)
+	assert.Empty(t, handlers.terminationProcedures)
+}
+
+func TestLoadPlanReturnsErrorForInvalidSetting(t *testing.T) {
+	t.Parallel()
+	handlers := _newHandlers(nil)
+	bindings := map[string]TerminationFunc{"close-db": func(os.Signal) error { return nil }}
+	for _, plan := range []string{"close-db timeout=soon", "close-db priority", "close-db color=red"} {
+		_, err := handlers.LoadPlan(strings.NewReader(plan), bindings)
+		assert.Error(t, err, plan)
+	}
+	assert.Empty(t, handlers.terminationProcedures)
+}
+
+func TestLoadPlanTimeoutAbandonsOnlyThatProcedure(t *testing.T) {
+	t.Parallel()
+	handlers := _newHandlers(nil)
+	block := make(chan struct{})
+	defer close(block)
+	_, err := handlers.LoadPlan(strings.NewReader("hang timeout=10ms\nclose-db"), map[string]TerminationFunc{
+		"hang":     func(os.Signal) error { <-block; return nil },
+		"close-db": func(os.Signal) error { return nil },
+	})
+	assert.NoError(t, err)
+
+	report := handlers.runTerminationProcedures(syscall.SIGTERM)
+	assert.Equal(t, TimedOut, report.Procedures[0].Status)
+	assert.Equal(t, ErrProcedureTimeout, report.Procedures[0].Err)
+	assert.Equal(t, Succeeded, report.Procedures[1].Status)
+	assert.Equal(t, "", report.TimedOutStage)
+	assert.Equal(t, ProcedureTimeoutExitCode, report.ExitCode)
+}
@@ -0,0 +1,121 @@
+package signal
+
+import (
+	"os"
+	"sync"
+)
+
+// DropPolicy decides what happens to a received signal when the dispatch queue is full.
+type DropPolicy int
+
+const (
+	// DropNewest drops the received signal.
+	DropNewest DropPolicy = iota
+	// DropOldest drops the oldest queued signal to make room for the received one.
+	DropOldest
+	// Block stops receiving until there is room, further signals wait in the listening channel.
+	Block
+
+	dropPolicyCount
+)
+
+func (p DropPolicy) String() string {
+	switch p {
+	case DropNewest:
+		return "drop newest"
+	case DropOldest:
+		return "drop oldest"
+	case Block:
+		return "block"
+	}
+	return "unknown"
+}
+
+// SetQueuePolicy makes received signals queued up to size before dispatched, policy applies when the queue is full.
+// Signals are dispatched in received order by a separate goroutine, size 0 disables the queue which is the default.
+// NOTE: it is applied on the next StartListen.
+func (s *Handlers) SetQueuePolicy(size int, policy DropPolicy) {
+	s.globalLock.Lock()
+	defer s.globalLock.Unlock()
+	if size < 0 {
+		size = 0
+	}
+	s.queueSize, s.queuePolicy = size, policy
+}
+
+// QueueDrops returns how many signals have been dropped by the dispatch queue applying policy.
+func (s *Handlers) QueueDrops(policy DropPolicy) uint64 {
+	if policy < 0 || policy >= dropPolicyCount {
+		return 0
+	}
+	return s.queueDrops[policy].Load()
+}
+
+func (s *Handlers) newSignalQueue() *signalQueue {
+	s.globalLock.RLock()
+	defer s.globalLock.RUnlock()
+	if s.queueSize == 0 {
+		return nil
+	}
+	queue := &signalQueue{size: s.queueSize, policy: s.queuePolicy}
+	queue.cond = sync.NewCond(&queue.lock)
+	return queue
+}
+
+type signalQueue struct {
+	lock    sync.Mutex
+	cond    *sync.Cond
+	signals []os.Signal
+	size    int
+	policy  DropPolicy
+	closed  bool
+}
+
+// push queues sig, it reports whether a signal is dropped.
+func (q *signalQueue) push(sig os.Signal) bool {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	defer q.cond.Broadcast()
+	for q.policy == Block && len(q.signals) >= q.size && !q.closed {
+		q.cond.Wait()
+	}
+	if q.closed {
+		return true
+	}
+
+	if len(q.signals) < q.size {
+		q.signals = append(q.signals, sig)
+		return false
+	}
+	if q.policy == DropOldest {
+		q.signals = append(q.signals[1:], sig)
+	}
+	return true
+}
+
+// dispatch calls handle with queued signals until the queue is closed.
+func (q *signalQueue) dispatch(handle func(os.Signal)) {
+	for {
+		q.lock.Lock()
+		for len(q.signals) == 0 && !q.closed {
+			q.cond.Wait()
+		}
+		if q.closed {
+			q.lock.Unlock()
+			return
+		}
+		sig := q.signals[0]
+		q.signals = q.signals[1:]
+		q.cond.Broadcast()
+		q.lock.Unlock()
+
+		handle(sig)
+	}
+}
+
+func (q *signalQueue) close() {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	q.closed = true
+	q.cond.Broadcast()
+}
@@ -0,0 +1,93 @@
+package signal
+
+import (
+	"os"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// startQueueTest starts listening to a source with a handler blocking on the first signal until release is closed.
+func startQueueTest(t *testing.T, size int, policy DropPolicy) (*Handlers, chan<- os.Signal, func() []os.Signal) {
+	source := make(chan os.Signal)
+	handlers := newHandlers(WithSignalSource(source), WithLogger(nopTestLogger{}))
+	handlers.SetQueuePolicy(size, policy)
+	entered, release := make(chan struct{}), make(chan struct{})
+	var lock sync.Mutex
+	var handled []os.Signal
+	handlers.RegisterSignalHandler(func(sig os.Signal) {
+		lock.Lock()
+		handled = append(handled, sig)
+		first := len(handled) == 1
+		lock.Unlock()
+		if first {
+			close(entered)
+			<-release
+		}
+	})
+	stop := handlers.StartListen()
+	t.Cleanup(stop)
+
+	source <- syscall.SIGUSR1
+	<-entered
+	return handlers, source, func() []os.Signal {
+		time.Sleep(time.Millisecond * 20)
+		close(release)
+		time.Sleep(time.Millisecond * 20)
+		lock.Lock()
+		defer lock.Unlock()
+		return handled
+	}
+}
+
+func TestHandlersQueueDropNewest(t *testing.T) {
+	t.Parallel()
+	handlers, source, finish := startQueueTest(t, 2, DropNewest)
+	source <- syscall.SIGUSR2
+	source <- syscall.SIGHUP
+	source <- syscall.SIGWINCH
+
+	assert.Equal(t, []os.Signal{syscall.SIGUSR1, syscall.SIGUSR2, syscall.SIGHUP}, finish())
+	assert.Equal(t, uint64(1), handlers.QueueDrops(DropNewest))
+}
+
+func TestHandlersQueueDropOldest(t *testing.T) {
+	t.Parallel()
+	handlers, source, finish := startQueueTest(t, 2, DropOldest)
+	source <- syscall.SIGUSR2
+	source <- syscall.SIGHUP
+	source <- syscall.SIGWINCH
+
+	assert.Equal(t, []os.Signal{syscall.SIGUSR1, syscall.SIGHUP, syscall.SIGWINCH}, finish())
+	assert.Equal(t, uint64(1), handlers.QueueDrops(DropOldest))
+}
+
+func TestHandlersQueueBlock(t *testing.T) {
+	t.Parallel()
+	handlers, source, finish := startQueueTest(t, 1, Block)
+	source <- syscall.SIGUSR2
+	source <- syscall.SIGHUP
+	sent := make(chan struct{})
+	go func() {
+		source <- syscall.SIGWINCH
+		close(sent)
+	}()
+
+	select {
+	case <-sent:
+		t.Fatal("receiving is not blocked by the full queue")
+	case <-time.After(time.Millisecond * 20):
+	}
+	assert.Equal(t, []os.Signal{syscall.SIGUSR1, syscall.SIGUSR2, syscall.SIGHUP, syscall.SIGWINCH}, finish())
+	assert.Equal(t, uint64(0), handlers.QueueDrops(Block))
+}
+
+func TestDropPolicyString(t *testing.T) {
+	assert.Equal(t, "drop newest", DropNewest.String())
+	assert.Equal(t, "drop oldest", DropOldest.String())
+	assert.Equal(t, "block", Block.String())
+	assert.Equal(t, "unknown", DropPolicy(42).String())
+}
@@ -0,0 +1,44 @@
+package signal
+
+import (
+	"io"
+	"os"
+)
+
+// EnableQuitStackDump makes SIGQUIT write the stacks of all goroutines then terminate, like the Go runtime does
+// unless the signal is listened. Stacks are written to os.Stderr, or the writer set by SetQuitStackDumpWriter.
+// NOTE: it is a no-op on platforms without SIGQUIT, e.g. Windows. If SIGQUIT is a termination signal, the stacks are
+// written before it is handled as usual.
+func (s *Handlers) EnableQuitStackDump() {
+	if quitSignal == nil {
+		s.log.Debug("quit signal is not supported on this platform")
+		return
+	}
+	entry := &handlerEntry{fn: func(sig os.Signal) {
+		s.globalLock.RLock()
+		w, terminates := s.quitDumpWriter, s.isTerminationSignal(sig)
+		s.globalLock.RUnlock()
+		if w == nil {
+			w = os.Stderr
+		}
+		if _, err := w.Write(allStacks()); err != nil {
+			logError(s.log, "error while writing goroutine stacks: ", err)
+		}
+		if !terminates {
+			s.handleTerminationSignals(sig)
+		}
+	}}
+
+	// it is called first, so stacks are written before any termination
+	s.globalLock.Lock()
+	defer s.globalLock.Unlock()
+	s.hasUserHandlers.Store(true)
+	s.handlers[quitSignal] = append([]*handlerEntry{entry}, s.handlers[quitSignal]...)
+}
+
+// SetQuitStackDumpWriter sets where EnableQuitStackDump writes goroutine stacks, os.Stderr is used if w is nil.
+func (s *Handlers) SetQuitStackDumpWriter(w io.Writer) {
+	s.globalLock.Lock()
+	defer s.globalLock.Unlock()
+	s.quitDumpWriter = w
+}
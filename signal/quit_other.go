@@ -0,0 +1,8 @@
+//go:build !unix
+
+package signal
+
+import "os"
+
+// quitSignal is the signal handled by EnableQuitStackDump, there is none on this platform.
+var quitSignal os.Signal
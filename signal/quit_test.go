@@ -0,0 +1,45 @@
+//go:build unix
+
+package signal
+
+import (
+	"bytes"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandlersQuitStackDumpWritesStacksThenTerminates(t *testing.T) {
+	t.Parallel()
+	var buf bytes.Buffer
+	code := -1
+	handlers := _newHandlers(func(c int) {
+		assert.Contains(t, buf.String(), "goroutine ")
+		code = c
+	})
+	handlers.SetLogger(nopTestLogger{})
+	handlers.SetQuitStackDumpWriter(&buf)
+	handlers.EnableQuitStackDump()
+
+	handlers.handleSignal(syscall.SIGQUIT)
+	assert.Contains(t, buf.String(), "TestHandlersQuitStackDumpWritesStacksThenTerminates")
+	assert.Equal(t, 0, code)
+}
+
+func TestHandlersQuitStackDumpTerminatesOnceIfTerminationSignal(t *testing.T) {
+	t.Parallel()
+	var buf bytes.Buffer
+	exits := 0
+	handlers := NewHandlers(syscall.SIGQUIT)
+	handlers.SetExit(func(int) {
+		assert.NotZero(t, buf.Len())
+		exits++
+	})
+	handlers.SetLogger(nopTestLogger{})
+	handlers.SetQuitStackDumpWriter(&buf)
+	handlers.EnableQuitStackDump()
+
+	handlers.handleSignal(syscall.SIGQUIT)
+	assert.Equal(t, 1, exits)
+}
@@ -0,0 +1,11 @@
+//go:build unix
+
+package signal
+
+import (
+	"os"
+	"syscall"
+)
+
+// quitSignal is the signal handled by EnableQuitStackDump.
+var quitSignal os.Signal = syscall.SIGQUIT
@@ -0,0 +1,54 @@
+package signal
+
+import (
+	"runtime/debug"
+)
+
+// DefaultPanicExitCode is the exit code used by RecoverAndShutdown unless SetPanicExitCode is called.
+const DefaultPanicExitCode = 2
+
+// RecoverAndShutdown recovers a panic then runs termination procedures and exits, it is meant to be deferred at the top of main.
+//
+//	func main() {
+//		handlers := signal.NewHandlers()
+//		defer handlers.RecoverAndShutdown()
+//		...
+//	}
+//
+// It does nothing if there is no panic. The process exits with the panic exit code like Terminate, or panics again
+// after running termination procedures if SetRepanic(true) is called, which keeps the original stack trace in the
+// crash output. If a termination is already in progress, it waits for it instead.
+func (s *Handlers) RecoverAndShutdown() {
+	v := recover()
+	if v == nil {
+		return
+	}
+
+	logError(s.log, "panic recovered: ", v, "\n", string(debug.Stack()))
+	s.globalLock.RLock()
+	code, repanic := s.panicExitCode, s.repanic
+	s.globalLock.RUnlock()
+	if repanic {
+		s.shutdown(SignalPanic)
+		panic(v)
+	}
+	if !s.terminate(SignalPanic, code) {
+		// another termination is in progress, it must not be cut short by returning from main
+		s.Wait()
+	}
+}
+
+// SetPanicExitCode sets the exit code used by RecoverAndShutdown, DefaultPanicExitCode by default.
+// Zero exits with the code selected from termination procedures, like Terminate(0).
+func (s *Handlers) SetPanicExitCode(code int) {
+	s.globalLock.Lock()
+	defer s.globalLock.Unlock()
+	s.panicExitCode = code
+}
+
+// SetRepanic sets whether RecoverAndShutdown panics again instead of exiting.
+func (s *Handlers) SetRepanic(repanic bool) {
+	s.globalLock.Lock()
+	defer s.globalLock.Unlock()
+	s.repanic = repanic
+}
@@ -0,0 +1,80 @@
+package signal
+
+import (
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/flexi-cache/pkg/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecoverAndShutdownRunsProceduresAndExits(t *testing.T) {
+	t.Parallel()
+	var code = -1
+	called := ""
+	handlers := _newHandlers(func(c int) { code = c })
+	handlers.SetLogger(nopTestLogger{})
+	handlers.RegisterTerminationProcedure(NewTerminationFunc(func() { called += "cleanup " }), "")
+
+	assert.NotPanics(t, func() {
+		defer handlers.RecoverAndShutdown()
+		panic("boom")
+	})
+	assert.Equal(t, "cleanup ", called)
+	assert.Equal(t, DefaultPanicExitCode, code)
+
+	handlers.SetPanicExitCode(9)
+	assert.NotPanics(t, func() {
+		defer handlers.RecoverAndShutdown()
+		panic("boom")
+	})
+	assert.Equal(t, 9, code)
+}
+
+func TestRecoverAndShutdownRunsTerminationHooks(t *testing.T) {
+	t.Parallel()
+	var steps []string
+	handlers := _newHandlers(func(c int) { steps = append(steps, "exit "+strconv.Itoa(c)) })
+	handlers.SetLogger(nopTestLogger{})
+	handlers.SetOnShutdownStart(func(sig os.Signal) { steps = append(steps, "start "+sig.String()) })
+	handlers.SetOnShutdownComplete(func(c int) { steps = append(steps, "complete "+strconv.Itoa(c)) })
+	handlers.SetPreExit(func(c int) { steps = append(steps, "pre exit "+strconv.Itoa(c)) })
+
+	assert.NotPanics(t, func() {
+		defer handlers.RecoverAndShutdown()
+		panic("boom")
+	})
+	code := DefaultPanicExitCode
+	assert.Equal(t, []string{"start panic", "complete " + strconv.Itoa(code), "pre exit " + strconv.Itoa(code),
+		"exit " + strconv.Itoa(code)}, steps)
+	testutil.AssertReturnsWithin(t, time.Second, func() { assert.Equal(t, code, handlers.Wait()) })
+}
+
+func TestRecoverAndShutdownPanicsAgainWhenRepanicSet(t *testing.T) {
+	t.Parallel()
+	exitCalled := false
+	called := ""
+	handlers := _newHandlers(func(int) { exitCalled = true })
+	handlers.SetLogger(nopTestLogger{})
+	handlers.RegisterTerminationProcedure(NewTerminationFunc(func() { called += "cleanup " }), "")
+	handlers.SetRepanic(true)
+
+	assert.PanicsWithValue(t, "boom", func() {
+		defer handlers.RecoverAndShutdown()
+		panic("boom")
+	})
+	assert.Equal(t, "cleanup ", called)
+	assert.False(t, exitCalled)
+}
+
+func TestRecoverAndShutdownDoesNothingWithoutPanic(t *testing.T) {
+	t.Parallel()
+	exitCalled := false
+	handlers := _newHandlers(func(int) { exitCalled = true })
+	func() {
+		defer handlers.RecoverAndShutdown()
+	}()
+	assert.False(t, exitCalled)
+}
@@ -0,0 +1,21 @@
+package signal
+
+import "os"
+
+// OnReload registers fn as the callback of SIGHUP, the result of fn is logged.
+// SIGHUP is never a termination signal by default, so reloading never exits the process.
+// NOTE: it is a no-op on platforms without SIGHUP, e.g. Windows.
+func (s *Handlers) OnReload(fn func() error) {
+	if reloadSignal == nil {
+		s.log.Debug("reload signal is not supported on this platform")
+		return
+	}
+	s.RegisterSignalHandler(func(os.Signal) {
+		s.log.Info("reloading")
+		if err := fn(); err != nil {
+			logError(s.log, "error while reloading: ", err)
+			return
+		}
+		s.log.Info("reloaded")
+	}, reloadSignal)
+}
@@ -0,0 +1,8 @@
+//go:build !unix
+
+package signal
+
+import "os"
+
+// reloadSignal is the signal handled by OnReload, there is none on this platform.
+var reloadSignal os.Signal
@@ -0,0 +1,34 @@
+//go:build unix
+
+package signal
+
+import (
+	"io"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandlersOnReloadRunsOnSIGHUPWithoutExit(t *testing.T) {
+	t.Parallel()
+	logger := &recordingLogger{}
+	exitCalled := false
+	handlers := _newHandlers(func(int) { exitCalled = true })
+	handlers.SetLogger(logger)
+	reloads := 0
+	handlers.OnReload(func() error {
+		reloads++
+		if reloads == 2 {
+			return io.EOF
+		}
+		return nil
+	})
+
+	handlers.handleSignal(syscall.SIGHUP)
+	assert.True(t, logger.contains("info: reloaded"))
+	handlers.handleSignal(syscall.SIGHUP)
+	assert.True(t, logger.contains("error: error while reloading: EOF"))
+	assert.Equal(t, 2, reloads)
+	assert.False(t, exitCalled)
+}
@@ -0,0 +1,11 @@
+//go:build unix
+
+package signal
+
+import (
+	"os"
+	"syscall"
+)
+
+// reloadSignal is the signal handled by OnReload.
+var reloadSignal os.Signal = syscall.SIGHUP
@@ -0,0 +1,165 @@
+package signal
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// ProcedureStatus describes how a termination procedure ended.
+type ProcedureStatus int
+
+const (
+	// Succeeded means the procedure returned nil.
+	Succeeded ProcedureStatus = iota
+	// Failed means the procedure returned an error.
+	Failed
+	// TimedOut means the procedure was still running when the shutdown timeout or its own timeout fired.
+	TimedOut
+	// Skipped means the procedure never ran because the shutdown timeout fired before it.
+	Skipped
+)
+
+func (p ProcedureStatus) String() string {
+	switch p {
+	case Succeeded:
+		return "succeeded"
+	case Failed:
+		return "failed"
+	case TimedOut:
+		return "timed out"
+	case Skipped:
+		return "skipped"
+	}
+	return "unknown"
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (p ProcedureStatus) MarshalText() ([]byte, error) {
+	return []byte(p.String()), nil
+}
+
+// ProcedureResult is the outcome of a single termination procedure.
+type ProcedureResult struct {
+	Message  string
+	Stage    string
+	Status   ProcedureStatus
+	Err      error
+	Duration time.Duration
+	// Stack is the stack of the procedure when it panicked, or timed out if SetCaptureStacksOnTimeout is on.
+	Stack string
+	// Panicked reports whether the procedure panicked, Err wraps ErrProcedurePanicked then.
+	Panicked bool
+	// Panic is the value recovered from the panic of the procedure.
+	Panic interface{}
+}
+
+// ShutdownReport describes what happened while running termination procedures.
+type ShutdownReport struct {
+	Signal   os.Signal
+	ExitCode int
+	// PID is the ID of the process shutting down.
+	PID int
+	// Uptime is how long the Handlers has existed before shutdown started.
+	Uptime     time.Duration
+	Procedures []ProcedureResult
+	// TimedOutStage is the stage which was running when the shutdown timeout fired, empty if it didn't.
+	TimedOutStage string
+}
+
+type jsonProcedureResult struct {
+	Message  string          `json:"message"`
+	Stage    string          `json:"stage,omitempty"`
+	Status   ProcedureStatus `json:"status"`
+	Error    string          `json:"error,omitempty"`
+	Duration string          `json:"duration"`
+	Stack    string          `json:"stack,omitempty"`
+	Panicked bool            `json:"panicked,omitempty"`
+	Panic    string          `json:"panic,omitempty"`
+}
+
+type jsonShutdownReport struct {
+	Signal        string                `json:"signal,omitempty"`
+	ExitCode      int                   `json:"exit_code"`
+	PID           int                   `json:"pid"`
+	Uptime        string                `json:"uptime"`
+	TimedOutStage string                `json:"timed_out_stage,omitempty"`
+	Procedures    []jsonProcedureResult `json:"procedures"`
+}
+
+// MarshalJSON implements json.Marshaler, signal and errors are written as strings.
+func (r ShutdownReport) MarshalJSON() ([]byte, error) {
+	out := jsonShutdownReport{
+		ExitCode:      r.ExitCode,
+		PID:           r.PID,
+		Uptime:        r.Uptime.String(),
+		TimedOutStage: r.TimedOutStage,
+		Procedures:    make([]jsonProcedureResult, 0, len(r.Procedures)),
+	}
+	if r.Signal != nil {
+		out.Signal = r.Signal.String()
+	}
+	for _, result := range r.Procedures {
+		procedure := jsonProcedureResult{
+			Message:  result.Message,
+			Stage:    result.Stage,
+			Status:   result.Status,
+			Duration: result.Duration.String(),
+			Stack:    result.Stack,
+			Panicked: result.Panicked,
+		}
+		if result.Err != nil {
+			procedure.Error = result.Err.Error()
+		}
+		if result.Panicked {
+			procedure.Panic = fmt.Sprint(result.Panic)
+		}
+		out.Procedures = append(out.Procedures, procedure)
+	}
+	return json.Marshal(out)
+}
+
+// SetReportWriter makes the ShutdownReport written to w as JSON after termination procedures are done, before exit.
+func (s *Handlers) SetReportWriter(w io.Writer) {
+	s.reportLock.Lock()
+	defer s.reportLock.Unlock()
+	s.reportWriter = w
+}
+
+// LastTerminationErrors returns the errors of termination procedures from the latest shutdown, in the order the
+// procedures ran. It is empty if all of them succeeded or no shutdown happened.
+func (s *Handlers) LastTerminationErrors() []error {
+	return s.LastShutdownReport().terminationErrors()
+}
+
+// terminationErrors returns the errors of termination procedures in report.
+func (r ShutdownReport) terminationErrors() []error {
+	var errs []error
+	for _, result := range r.Procedures {
+		if result.Err != nil {
+			errs = append(errs, result.Err)
+		}
+	}
+	return errs
+}
+
+// recordReport keeps report as the last one and writes it to the report writer if any.
+func (s *Handlers) recordReport(report ShutdownReport) {
+	s.reportLock.Lock()
+	s.lastReport = report
+	w := s.reportWriter
+	s.reportLock.Unlock()
+	if w == nil {
+		return
+	}
+
+	data, err := json.Marshal(report)
+	if err == nil {
+		_, err = w.Write(append(data, '\n'))
+	}
+	if err != nil {
+		logError(s.log, "error while writing shutdown report: ", err)
+	}
+}
@@ -0,0 +1,133 @@
+package signal
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandlersWritesReportAsJSONBeforeExit(t *testing.T) {
+	t.Parallel()
+	var buf bytes.Buffer
+	var written string
+	handlers := _newHandlers(func(int) { written = buf.String() })
+	handlers.SetReportWriter(&buf)
+	handlers.RegisterTerminationProcedureInStage(func(os.Signal) error {
+		return WrapErrorWithCode(io.EOF, 3)
+	}, "flush", "drain")
+	handlers.handleSignal(syscall.SIGTERM)
+
+	var report map[string]interface{}
+	assert.NoError(t, json.Unmarshal([]byte(written), &report))
+	assert.Equal(t, syscall.SIGTERM.String(), report["signal"])
+	assert.Equal(t, float64(3), report["exit_code"])
+	procedure := report["procedures"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, "flush", procedure["message"])
+	assert.Equal(t, "drain", procedure["stage"])
+	assert.Equal(t, "failed", procedure["status"])
+	assert.Equal(t, "EOF", procedure["error"])
+}
+
+func TestProcedureStatusString(t *testing.T) {
+	for status, expected := range map[ProcedureStatus]string{
+		Succeeded: "succeeded", Failed: "failed", TimedOut: "timed out", Skipped: "skipped", ProcedureStatus(-1): "unknown",
+	} {
+		assert.Equal(t, expected, status.String())
+	}
+}
+
+func TestHandlersSimulateShutdownRunsProceduresWithoutExit(t *testing.T) {
+	t.Parallel()
+	var buf bytes.Buffer
+	exitCalled := false
+	handlers := _newHandlers(func(int) { exitCalled = true })
+	handlers.SetLogger(nopTestLogger{})
+	handlers.SetReportWriter(&buf)
+	called := 0
+	handlers.RegisterTerminationProcedure(func(os.Signal) error {
+		called++
+		return io.EOF
+	}, "flush")
+
+	for i := 1; i <= 2; i++ {
+		report := handlers.SimulateShutdown(syscall.SIGTERM)
+		assert.Equal(t, i, called)
+		assert.Equal(t, 1, report.ExitCode)
+		assert.Len(t, report.Procedures, 1)
+	}
+	assert.False(t, exitCalled)
+	assert.Empty(t, buf.String())
+	assert.Equal(t, ShutdownReport{}, handlers.LastShutdownReport())
+}
+
+func TestHandlersReportsPIDAndUptime(t *testing.T) {
+	t.Parallel()
+	logger := &recordingLogger{}
+	handlers := _newHandlers(nil)
+	handlers.SetLogger(logger)
+	time.Sleep(time.Millisecond * 5)
+
+	report := handlers.runTerminationProcedures(syscall.SIGTERM)
+	assert.Equal(t, os.Getpid(), report.PID)
+	assert.GreaterOrEqual(t, report.Uptime, time.Millisecond*5)
+	assert.True(t, logger.contains(fmt.Sprint("pid: ", os.Getpid())))
+
+	data, err := json.Marshal(report)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), fmt.Sprintf(`"pid":%d`, os.Getpid()))
+}
+
+func TestHandlersReportsPanickedProcedure(t *testing.T) {
+	t.Parallel()
+	var code int
+	handlers := _newHandlers(func(c int) { code = c })
+	handlers.SetLogger(nopTestLogger{})
+	called := false
+	handlers.RegisterTerminationProcedure(func(os.Signal) error { panic("boom") }, "explode")
+	handlers.RegisterTerminationProcedure(func(os.Signal) error {
+		called = true
+		return nil
+	}, "after")
+	handlers.handleSignal(syscall.SIGTERM)
+
+	report := handlers.LastShutdownReport()
+	result := report.Procedures[0]
+	assert.True(t, result.Panicked)
+	assert.Equal(t, "boom", result.Panic)
+	assert.Equal(t, Failed, result.Status)
+	assert.ErrorIs(t, result.Err, ErrProcedurePanicked)
+	assert.Contains(t, result.Stack, "panic")
+	assert.False(t, report.Procedures[1].Panicked)
+	assert.True(t, called)
+	assert.Equal(t, 1, code)
+
+	data, err := json.Marshal(report)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `"panicked":true,"panic":"boom"`)
+}
+
+func TestHandlersKeepsAllTerminationErrors(t *testing.T) {
+	t.Parallel()
+	var code int
+	handlers := _newHandlers(func(c int) { code = c })
+	handlers.SetLogger(nopTestLogger{})
+	assert.Empty(t, handlers.LastTerminationErrors())
+	handlers.RegisterTerminationProcedure(func(os.Signal) error { return WrapErrorWithCode(io.EOF, 3) }, "flush")
+	handlers.RegisterTerminationProcedure(func(os.Signal) error { return nil }, "close")
+	handlers.RegisterTerminationProcedure(func(os.Signal) error { return io.ErrClosedPipe }, "drain")
+	handlers.handleSignal(syscall.SIGTERM)
+
+	errs := handlers.LastTerminationErrors()
+	if assert.Len(t, errs, 2) {
+		assert.ErrorIs(t, errs[0], io.EOF)
+		assert.Equal(t, io.ErrClosedPipe, errs[1])
+	}
+	assert.Equal(t, 3, code)
+}
@@ -0,0 +1,279 @@
+package signal
+
+import (
+	"context"
+	"os"
+	"time"
+)
+
+// ShutdownObserver is notified around termination procedures, see AddShutdownObserver.
+type ShutdownObserver interface {
+	// BeforeShutdown is called before any termination procedure runs.
+	BeforeShutdown(sig os.Signal)
+	// AfterShutdown is called once termination procedures are done, before final procedures run.
+	AfterShutdown(report ShutdownReport)
+}
+
+// AddShutdownObserver adds an observer of shutdown, observers are notified in the order they are added.
+func (s *Handlers) AddShutdownObserver(o ShutdownObserver) {
+	s.globalLock.Lock()
+	defer s.globalLock.Unlock()
+	s.observers = append(s.observers, o)
+}
+
+// OnDrainStart adds fn called before any termination procedure runs, e.g. to fail readiness checks so that load
+// balancers stop routing to the process. It is notified like a ShutdownObserver, in the order added.
+func (s *Handlers) OnDrainStart(fn func()) {
+	s.AddShutdownObserver(drainHook{start: fn})
+}
+
+// OnDrainComplete adds fn called once termination procedures are done, before final procedures run and exit.
+// It is notified like a ShutdownObserver, in the order added.
+func (s *Handlers) OnDrainComplete(fn func()) {
+	s.AddShutdownObserver(drainHook{complete: fn})
+}
+
+// drainHook adapts the functions given to OnDrainStart and OnDrainComplete to ShutdownObserver.
+type drainHook struct {
+	start, complete func()
+}
+
+func (h drainHook) BeforeShutdown(os.Signal) {
+	if h.start != nil {
+		h.start()
+	}
+}
+
+func (h drainHook) AfterShutdown(ShutdownReport) {
+	if h.complete != nil {
+		h.complete()
+	}
+}
+
+// RegisterFinalProcedure registers a procedure run after observers are notified of the end of shutdown,
+// e.g. to flush telemetry. Final procedures are not bound by the shutdown timeout and their results are
+// appended to the report.
+func (s *Handlers) RegisterFinalProcedure(fn TerminationFunc, message string) {
+	s.globalLock.Lock()
+	defer s.globalLock.Unlock()
+	s.finalProcedures = append(s.finalProcedures, terminationProcedure{fn: fn.withContext(), message: message})
+}
+
+// SetOnComplete sets the function called with the report right before exit.
+func (s *Handlers) SetOnComplete(fn func(ShutdownReport)) {
+	s.globalLock.Lock()
+	defer s.globalLock.Unlock()
+	s.onComplete = fn
+}
+
+// SetOnShutdownStart sets the function called with the signal once a termination starts, before anything else runs.
+// NOTE: it is called for terminations by signals or Terminate, not for Shutdown which never exits.
+func (s *Handlers) SetOnShutdownStart(fn func(sig os.Signal)) {
+	s.globalLock.Lock()
+	defer s.globalLock.Unlock()
+	s.onShutdownStart = fn
+}
+
+// SetOnShutdownComplete sets the function called with the exit code once a termination is done, right before exit.
+// NOTE: it is called for terminations by signals or Terminate, not for Shutdown which never exits.
+func (s *Handlers) SetOnShutdownComplete(fn func(code int)) {
+	s.globalLock.Lock()
+	defer s.globalLock.Unlock()
+	s.onShutdownComplete = fn
+}
+
+// SetPreExit sets the function called with the exit code immediately before exit, after the goodbye line, e.g. to
+// flush buffered log writers or metrics. It is called even if termination procedures failed.
+// NOTE: it is not called on force exits, nor for Shutdown which never exits.
+func (s *Handlers) SetPreExit(fn func(code int)) {
+	s.globalLock.Lock()
+	defer s.globalLock.Unlock()
+	s.preExit = fn
+}
+
+// flusher is implemented by loggers which buffer their output, they are flushed after the last line is logged.
+type flusher interface {
+	Flush() error
+}
+
+// terminate shuts down for sig then exits with code, or the code selected from termination procedures if code is 0.
+// It is dropped while another termination is in progress, so termination procedures run at most once at a time,
+// ok reports whether it ran.
+func (s *Handlers) terminate(sig os.Signal, code int) (ok bool) {
+	done, ok := s.beginTermination(sig)
+	if !ok {
+		return false
+	}
+	defer s.endTermination(done)
+	s.runTermination(sig, code)
+	return true
+}
+
+// runTermination terminates like terminate, the caller must have begun the termination.
+func (s *Handlers) runTermination(sig os.Signal, code int) {
+	s.globalLock.RLock()
+	onStart, onComplete, preExit, exit := s.onShutdownStart, s.onShutdownComplete, s.preExit, s.exit
+	s.globalLock.RUnlock()
+	if onStart != nil {
+		onStart(sig)
+	}
+	report := s.runShutdown(sig)
+	if code == 0 {
+		code = report.ExitCode
+	}
+	if onComplete != nil {
+		onComplete(code)
+	}
+	s.markTerminated(code)
+	s.logMessage(s.getMessages().Goodbye)
+	s.flushLogger()
+	if preExit != nil {
+		preExit(code)
+	}
+	exit(code)
+}
+
+// Wait blocks until termination procedures have run for a termination, then returns the selected exit code.
+// It returns right before exit is called, so the process may exit at any time afterwards.
+// It is safe to call from multiple goroutines, all of them get the code of the first termination.
+//
+//	stop := handlers.StartListen()
+//	defer stop()
+//	handlers.Wait()
+func (s *Handlers) Wait() int {
+	<-s.terminated
+	return s.terminatedCode
+}
+
+// Run starts listening and blocks until a termination is done, then returns the selected exit code and the errors of
+// failed termination procedures joined. It never exits, the process is left to the caller, e.g. to integration tests.
+// NOTE: exit is disabled while Run is listening, including forced exits, the previous exit is restored once the
+// termination has ended. s must not be listening already.
+func (s *Handlers) Run() (code int, err error) {
+	exit := s.getExit()
+	s.SetExit(func(int) {})
+	defer s.SetExit(exit)
+	stop := s.StartListen()
+	code = s.Wait()
+	stop()
+	s.waitTerminationEnded()
+	return code, s.LastShutdownReport().errors()
+}
+
+func (s *Handlers) markTerminated(code int) {
+	s.terminatedOnce.Do(func() {
+		s.terminatedCode = code
+		close(s.terminated)
+	})
+}
+
+// shutdown runs the termination sequence for sig like terminate but never exits, ok is false if it is dropped since
+// another termination is in progress.
+func (s *Handlers) shutdown(sig os.Signal) (report ShutdownReport, ok bool) {
+	done, ok := s.beginTermination(sig)
+	if !ok {
+		return ShutdownReport{}, false
+	}
+	defer s.endTermination(done)
+	report = s.runShutdown(sig)
+	s.flushLogger()
+	return report, true
+}
+
+// runShutdown cancels the listen context then runs the termination sequence for sig.
+func (s *Handlers) runShutdown(sig os.Signal) ShutdownReport {
+	run, owner := s.startShutdownRun(false)
+	if !owner {
+		s.log.Info("termination procedures already run by Shutdown")
+		<-run.done
+		return run.report
+	}
+	s.stopListen(sig)
+	// the context is created first so it is done before the shutdown deadline forces exit
+	ctx, cancel := s.shutdownContext()
+	defer cancel()
+	defer s.armShutdownDeadline()()
+	report := s.runSequence(ctx, sig)
+	run.finish(report)
+	return report
+}
+
+// runSequence runs every step of termination but exit, in this order:
+//  1. observers are notified before shutdown
+//  2. termination procedures run until ctx is done
+//  3. observers are notified after shutdown
+//  4. final procedures run
+//  5. the report is recorded and metrics are observed
+//  6. the function set by SetOnComplete is called
+func (s *Handlers) runSequence(ctx context.Context, sig os.Signal) ShutdownReport {
+	start := time.Now()
+	s.notifyBeforeShutdown(sig)
+	report := s.runTerminationProceduresWithin(ctx, sig)
+	s.notifyAfterShutdown(report)
+	s.runFinalProcedures(sig, &report)
+	s.recordReport(report)
+	s.observeMetrics(report, time.Since(start))
+	s.complete(report)
+	return report
+}
+
+func (s *Handlers) notifyBeforeShutdown(sig os.Signal) {
+	s.globalLock.RLock()
+	observers := s.observers
+	s.globalLock.RUnlock()
+	for _, o := range observers {
+		o.BeforeShutdown(sig)
+	}
+}
+
+func (s *Handlers) notifyAfterShutdown(report ShutdownReport) {
+	s.globalLock.RLock()
+	observers := s.observers
+	s.globalLock.RUnlock()
+	for _, o := range observers {
+		o.AfterShutdown(report)
+	}
+}
+
+// runFinalProcedures runs final procedures and appends their results to report.
+func (s *Handlers) runFinalProcedures(sig os.Signal, report *ShutdownReport) {
+	s.globalLock.RLock()
+	procedures, strategy, defaultCode := s.finalProcedures, s.exitCodeStrategy, s.defaultErrorCode
+	extractCode := s.exitCodeExtractor
+	s.globalLock.RUnlock()
+	defer s.inFlight.Store("")
+	for _, proc := range procedures {
+		s.log.Info(proc.message)
+		s.inFlight.Store(proc.message)
+		start := time.Now()
+		outcome := callProcedure(context.Background(), proc, sig)
+		result := ProcedureResult{Message: proc.message, Status: Succeeded, Err: outcome.err, Duration: time.Since(start)}
+		result.Stack, result.Panicked, result.Panic = outcome.stack, outcome.panicked, outcome.panicValue
+		if result.Err != nil {
+			result.Status = Failed
+			logError(s.log, "error while running final procedure: ", result.Err)
+			report.ExitCode = strategy.fold(report.ExitCode, procedureExitCode(result.Err, defaultCode, extractCode))
+		}
+		report.Procedures = append(report.Procedures, result)
+	}
+}
+
+// flushLogger flushes the logger if it buffers its output.
+func (s *Handlers) flushLogger() {
+	f, ok := s.log.(flusher)
+	if !ok {
+		return
+	}
+	if err := f.Flush(); err != nil {
+		logError(s.log, "error while flushing logger: ", err)
+	}
+}
+
+func (s *Handlers) complete(report ShutdownReport) {
+	s.globalLock.RLock()
+	onComplete := s.onComplete
+	s.globalLock.RUnlock()
+	if onComplete != nil {
+		onComplete(report)
+	}
+}
@@ -0,0 +1,194 @@
+package signal
+
+import (
+	"io"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type recordingObserver struct {
+	steps *[]string
+}
+
+func (o recordingObserver) BeforeShutdown(os.Signal) {
+	*o.steps = append(*o.steps, "observer before")
+}
+
+func (o recordingObserver) AfterShutdown(ShutdownReport) {
+	*o.steps = append(*o.steps, "observer after")
+}
+
+type flushingLogger struct {
+	nopTestLogger
+	steps *[]string
+}
+
+func (l flushingLogger) Info(args ...interface{}) {
+	if len(args) == 1 && args[0] == DefaultMessages.Goodbye {
+		*l.steps = append(*l.steps, "goodbye")
+	}
+}
+
+func (l flushingLogger) Flush() error {
+	*l.steps = append(*l.steps, "logger flush")
+	return nil
+}
+
+func TestHandlersTerminatesInDocumentedOrder(t *testing.T) {
+	t.Parallel()
+	var steps []string
+	handlers := _newHandlers(func(code int) {
+		steps = append(steps, "exit")
+		assert.Equal(t, 3, code)
+	})
+	handlers.SetLogger(flushingLogger{steps: &steps})
+	handlers.AddShutdownObserver(recordingObserver{steps: &steps})
+	handlers.RegisterTerminationProcedure(func(os.Signal) error {
+		steps = append(steps, "procedure")
+		return nil
+	}, "procedure")
+	handlers.RegisterFinalProcedure(func(os.Signal) error {
+		steps = append(steps, "final procedure")
+		return WrapErrorWithCode(io.EOF, 3)
+	}, "final procedure")
+	handlers.SetOnComplete(func(report ShutdownReport) {
+		steps = append(steps, "complete")
+		if assert.Len(t, report.Procedures, 2) {
+			assert.Equal(t, Failed, report.Procedures[1].Status)
+		}
+	})
+	handlers.handleSignal(syscall.SIGTERM)
+
+	assert.Equal(t, []string{
+		"observer before", "procedure", "observer after", "final procedure", "complete", "goodbye", "logger flush", "exit",
+	}, steps)
+}
+
+func TestHandlersDrainHooksSurroundProcedures(t *testing.T) {
+	t.Parallel()
+	var steps []string
+	ret := -1
+	handlers := _newHandlers(func(code int) {
+		steps = append(steps, "exit")
+		ret = code
+	})
+	handlers.SetLogger(nopTestLogger{})
+	handlers.OnDrainStart(func() { steps = append(steps, "drain start") })
+	handlers.OnDrainComplete(func() { steps = append(steps, "drain complete") })
+	handlers.RegisterTerminationProcedure(func(os.Signal) error {
+		steps = append(steps, "procedure")
+		return WrapErrorWithCode(io.EOF, 3)
+	}, "procedure")
+	handlers.RegisterFinalProcedure(func(os.Signal) error {
+		steps = append(steps, "final procedure")
+		return nil
+	}, "final procedure")
+	handlers.handleSignal(syscall.SIGTERM)
+
+	assert.Equal(t, []string{"drain start", "procedure", "drain complete", "final procedure", "exit"}, steps)
+	assert.Equal(t, 3, ret)
+}
+
+func TestHandlersShutdownHooksFireOncePerTermination(t *testing.T) {
+	t.Parallel()
+	var steps []string
+	handlers := _newHandlers(func(int) { steps = append(steps, "exit") })
+	handlers.SetLogger(nopTestLogger{})
+	handlers.SetOnShutdownStart(func(sig os.Signal) { steps = append(steps, "start "+sig.String()) })
+	handlers.SetOnShutdownComplete(func(code int) { steps = append(steps, "complete "+strconv.Itoa(code)) })
+	handlers.RegisterTerminationProcedure(func(os.Signal) error {
+		steps = append(steps, "procedure")
+		return WrapErrorWithCode(io.EOF, 3)
+	}, "procedure")
+
+	handlers.handleSignal(syscall.SIGTERM)
+	assert.Equal(t, []string{"start terminated", "procedure", "complete 3", "exit"}, steps)
+
+	steps = nil
+	handlers.Terminate(5)
+	assert.Equal(t, []string{"start " + SignalProgrammatic.String(), "procedure", "complete 5", "exit"}, steps)
+}
+
+func TestHandlersPreExitRunsRightBeforeExit(t *testing.T) {
+	t.Parallel()
+	var steps []string
+	logger := &recordingLogger{}
+	handlers := _newHandlers(func(code int) { steps = append(steps, "exit "+strconv.Itoa(code)) })
+	handlers.SetLogger(logger)
+	handlers.SetPreExit(func(code int) {
+		assert.True(t, logger.contains("info: bye"))
+		steps = append(steps, "pre exit "+strconv.Itoa(code))
+	})
+	handlers.RegisterTerminationProcedure(func(os.Signal) error {
+		steps = append(steps, "procedure")
+		return WrapErrorWithCode(io.EOF, 4)
+	}, "procedure")
+
+	handlers.handleSignal(syscall.SIGTERM)
+	assert.Equal(t, []string{"procedure", "pre exit 4", "exit 4"}, steps)
+}
+
+func TestHandlersWaitReturnsExitCodeOnceTerminated(t *testing.T) {
+	t.Parallel()
+	handlers := _newHandlers(func(int) {})
+	handlers.SetLogger(nopTestLogger{})
+	handlers.RegisterTerminationProcedure(func(os.Signal) error { return WrapErrorWithCode(io.EOF, 3) }, "")
+
+	codes := make(chan int, 2)
+	for i := 0; i < 2; i++ {
+		go func() { codes <- handlers.Wait() }()
+	}
+	select {
+	case <-codes:
+		t.Fatal("Wait returned before termination")
+	case <-time.After(time.Millisecond * 10):
+	}
+
+	handlers.handleSignal(syscall.SIGTERM)
+	handlers.Terminate(5)
+	assert.Equal(t, 3, <-codes)
+	assert.Equal(t, 3, <-codes)
+	assert.Equal(t, 3, handlers.Wait())
+}
+
+// NOTE: it must not run in parallel since the signals are sent to the whole test process.
+func TestHandlersRunReturnsCodeOnTermination(t *testing.T) {
+	// keep SIGTERM from killing the process until Run listens to it
+	guard := make(chan os.Signal, 1)
+	signal.Notify(guard, syscall.SIGTERM)
+	defer func() {
+		time.Sleep(time.Millisecond * 50)
+		signal.Stop(guard)
+	}()
+
+	exited := make(chan int, 1)
+	handlers := _newHandlers(func(code int) { exited <- code })
+	handlers.SetLogger(nopTestLogger{})
+	handlers.RegisterTerminationProcedure(func(os.Signal) error { return WrapErrorWithCode(io.EOF, 3) }, "failing")
+	done := make(chan struct{})
+	go func() {
+		for {
+			syscall.Kill(os.Getpid(), syscall.SIGTERM)
+			select {
+			case <-done:
+				return
+			case <-time.After(time.Millisecond * 20):
+			}
+		}
+	}()
+	code, err := handlers.Run()
+	close(done)
+	assert.Equal(t, 3, code)
+	assert.ErrorIs(t, err, io.EOF)
+	assert.Len(t, exited, 0)
+
+	// the exit in place before Run is restored
+	handlers.Dispatch(syscall.SIGTERM)
+	assert.Equal(t, 3, <-exited)
+}
@@ -0,0 +1,78 @@
+package signal
+
+import (
+	"context"
+	"errors"
+)
+
+// Shutdowner is implemented by Handlers, frameworks commonly accept it to shut down their components.
+type Shutdowner interface {
+	Shutdown(ctx context.Context) error
+}
+
+var _ Shutdowner = (*Handlers)(nil)
+
+// shutdownRun is a run of termination procedures, done is closed once report is set.
+type shutdownRun struct {
+	done   chan struct{}
+	report ShutdownReport
+}
+
+func (r *shutdownRun) finish(report ShutdownReport) {
+	r.report = report
+	close(r.done)
+}
+
+// Shutdown runs termination procedures with SignalProgrammatic until ctx is done, it never exits.
+// It returns the errors of failed termination procedures joined, or the error of ctx if it is done before
+// termination procedures run by an earlier call or a termination signal are done.
+// Termination procedures run at most once for Shutdown: later calls and termination signals reuse its result,
+// and a Shutdown during a signal-triggered termination waits for it instead.
+func (s *Handlers) Shutdown(ctx context.Context) error {
+	run, owner := s.startShutdownRun(true)
+	if owner {
+		s.log.Info("shutdown requested")
+		s.stopListen(SignalProgrammatic)
+		run.finish(s.runSequence(ctx, SignalProgrammatic))
+		s.flushLogger()
+	}
+
+	// a finished run wins over a done ctx, select would pick either
+	select {
+	case <-run.done:
+		return run.report.errors()
+	default:
+	}
+	select {
+	case <-run.done:
+		return run.report.errors()
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// startShutdownRun returns the run to wait for, owner reports whether the caller has to run termination procedures.
+// Signal-triggered terminations only reuse a run started by Shutdown, Shutdown reuses any.
+func (s *Handlers) startShutdownRun(graceful bool) (run *shutdownRun, owner bool) {
+	s.runLock.Lock()
+	defer s.runLock.Unlock()
+	if s.gracefulRun != nil {
+		return s.gracefulRun, false
+	}
+	if graceful && s.lastRun != nil {
+		s.gracefulRun = s.lastRun
+		return s.lastRun, false
+	}
+
+	run = &shutdownRun{done: make(chan struct{})}
+	s.lastRun = run
+	if graceful {
+		s.gracefulRun = run
+	}
+	return run, true
+}
+
+// errors joins the errors of termination procedures in report.
+func (r ShutdownReport) errors() error {
+	return errors.Join(r.terminationErrors()...)
+}
@@ -0,0 +1,91 @@
+package signal
+
+import (
+	"context"
+	"io"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandlersShutdownRunsProceduresWithContextDeadline(t *testing.T) {
+	t.Parallel()
+	exitCalled := false
+	handlers := _newHandlers(func(int) { exitCalled = true })
+	handlers.SetLogger(nopTestLogger{})
+	var deadline time.Time
+	handlers.RegisterTerminationProcedureCtx(func(ctx context.Context, sig os.Signal) error {
+		deadline, _ = ctx.Deadline()
+		assert.Equal(t, SignalProgrammatic, sig)
+		return io.EOF
+	}, "flush")
+	handlers.RegisterTerminationProcedure(func(os.Signal) error { return io.ErrUnexpectedEOF }, "close")
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	err := handlers.Shutdown(ctx)
+	assert.ErrorIs(t, err, io.EOF)
+	assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+	expected, _ := ctx.Deadline()
+	assert.Equal(t, expected, deadline)
+	assert.False(t, exitCalled)
+}
+
+func TestHandlersShutdownRunsProceduresOnce(t *testing.T) {
+	t.Parallel()
+	var code int
+	handlers := _newHandlers(func(c int) { code = c })
+	handlers.SetLogger(nopTestLogger{})
+	called := 0
+	handlers.RegisterTerminationProcedure(func(os.Signal) error {
+		called++
+		return WrapErrorWithCode(io.EOF, 3)
+	}, "flush")
+
+	assert.ErrorIs(t, handlers.Shutdown(context.Background()), io.EOF)
+	assert.ErrorIs(t, handlers.Shutdown(context.Background()), io.EOF)
+	handlers.handleSignal(syscall.SIGTERM)
+	assert.Equal(t, 1, called)
+	assert.Equal(t, 3, code)
+}
+
+func TestHandlersShutdownReusesSignalTriggeredTermination(t *testing.T) {
+	t.Parallel()
+	handlers := _newHandlers(func(int) {})
+	handlers.SetLogger(nopTestLogger{})
+	called := 0
+	handlers.RegisterTerminationProcedure(func(os.Signal) error {
+		called++
+		return nil
+	}, "flush")
+
+	handlers.handleSignal(syscall.SIGTERM)
+	assert.NoError(t, handlers.Shutdown(context.Background()))
+	assert.Equal(t, 1, called)
+}
+
+func TestHandlersShutdownPrefersFinishedRunOverDoneContext(t *testing.T) {
+	t.Parallel()
+	handlers := _newHandlers(func(int) {})
+	handlers.SetLogger(nopTestLogger{})
+	handlers.RegisterTerminationProcedure(func(os.Signal) error { return io.EOF }, "flush")
+	assert.ErrorIs(t, handlers.Shutdown(context.Background()), io.EOF)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	for i := 0; i < 20; i++ {
+		assert.ErrorIs(t, handlers.Shutdown(ctx), io.EOF)
+	}
+}
+
+func TestHandlersShutdownFlushesLogger(t *testing.T) {
+	t.Parallel()
+	var steps []string
+	handlers := _newHandlers(func(int) {})
+	handlers.SetLogger(flushingLogger{steps: &steps})
+	assert.NoError(t, handlers.Shutdown(context.Background()))
+	assert.Equal(t, []string{"logger flush"}, steps)
+}
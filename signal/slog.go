@@ -0,0 +1,45 @@
+package signal
+
+import (
+	"fmt"
+	"log/slog"
+	"sort"
+)
+
+// NewSlogLogger adapts l to ErrorLogger, WarnLogger and FieldLogger, the args of a log call are joined into the message like fmt.Sprint.
+func NewSlogLogger(l *slog.Logger) Logger {
+	return slogLogger{l: l}
+}
+
+type slogLogger struct {
+	l *slog.Logger
+}
+
+func (s slogLogger) Debug(args ...interface{}) {
+	s.l.Debug(fmt.Sprint(args...))
+}
+
+func (s slogLogger) Info(args ...interface{}) {
+	s.l.Info(fmt.Sprint(args...))
+}
+
+func (s slogLogger) Warn(args ...interface{}) {
+	s.l.Warn(fmt.Sprint(args...))
+}
+
+func (s slogLogger) Error(args ...interface{}) {
+	s.l.Error(fmt.Sprint(args...))
+}
+
+func (s slogLogger) WithFields(fields map[string]interface{}) Logger {
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	args := make([]interface{}, 0, len(fields)*2)
+	for _, key := range keys {
+		args = append(args, key, fields[key])
+	}
+	return slogLogger{l: s.l.With(args...)}
+}
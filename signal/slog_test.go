@@ -0,0 +1,31 @@
+package signal
+
+import (
+	"bytes"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSlogLoggerMapsLevels(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewSlogLogger(slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{
+		Level: slog.LevelDebug,
+		ReplaceAttr: func(_ []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			return a
+		},
+	})))
+
+	logger.Debug("registered termination procedure for: ", "flush")
+	logger.Info("shutting down, pid: ", 42)
+	logger.(WarnLogger).Warn("termination procedure timed out: ", "flush")
+	logger.(ErrorLogger).Error("error while running termination procedure: ", "flush")
+	assert.Equal(t, "level=DEBUG msg=\"registered termination procedure for: flush\"\n"+
+		"level=INFO msg=\"shutting down, pid: 42\"\n"+
+		"level=WARN msg=\"termination procedure timed out: flush\"\n"+
+		"level=ERROR msg=\"error while running termination procedure: flush\"\n", buf.String())
+}
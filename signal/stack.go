@@ -0,0 +1,44 @@
+package signal
+
+import (
+	"bytes"
+	"runtime"
+	"strconv"
+)
+
+var goroutinePrefix = []byte("goroutine ")
+
+// currentGoroutineID parses the ID of the calling goroutine from its stack header, e.g. "goroutine 42 [running]:".
+func currentGoroutineID() uint64 {
+	buf := make([]byte, 64)
+	buf = buf[:runtime.Stack(buf, false)]
+	buf = bytes.TrimPrefix(buf, goroutinePrefix)
+	if i := bytes.IndexByte(buf, ' '); i > 0 {
+		buf = buf[:i]
+	}
+	id, _ := strconv.ParseUint(string(buf), 10, 64)
+	return id
+}
+
+// goroutineStack returns the stack of goroutine id, or stacks of all goroutines if it is not found.
+func goroutineStack(id uint64) string {
+	all := allStacks()
+	header := append(append([]byte(nil), goroutinePrefix...), strconv.FormatUint(id, 10)+" "...)
+	for _, stack := range bytes.Split(all, []byte("\n\n")) {
+		if bytes.HasPrefix(stack, header) {
+			return string(stack)
+		}
+	}
+	return string(all)
+}
+
+func allStacks() []byte {
+	buf := make([]byte, 1<<16)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) {
+			return buf[:n]
+		}
+		buf = make([]byte, len(buf)*2)
+	}
+}
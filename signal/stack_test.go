@@ -0,0 +1,55 @@
+package signal
+
+import (
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func hangingProcedureForStackTest(block chan struct{}) TerminationFunc {
+	return func(os.Signal) error {
+		<-block
+		return nil
+	}
+}
+
+func TestHandlersCapturesStackOfTimedOutProcedure(t *testing.T) {
+	t.Parallel()
+	block := make(chan struct{})
+	defer close(block)
+	logger := &recordingLogger{}
+	handlers := _newHandlers(nil)
+	handlers.SetLogger(logger)
+	handlers.SetCaptureStacksOnTimeout(true)
+	handlers.SetShutdownTimeout(time.Millisecond * 20)
+	handlers.RegisterTerminationProcedure(hangingProcedureForStackTest(block), "hanging")
+
+	report := handlers.runTerminationProcedures(syscall.SIGTERM)
+	stack := report.Procedures[0].Stack
+	assert.Contains(t, stack, "hangingProcedureForStackTest")
+	assert.NotContains(t, stack, "TestHandlersCapturesStackOfTimedOutProcedure")
+	assert.True(t, logger.contains("stack of the timed out termination procedure"))
+}
+
+func TestHandlersDoesNotCaptureStackByDefault(t *testing.T) {
+	t.Parallel()
+	block := make(chan struct{})
+	defer close(block)
+	handlers := _newHandlers(nil)
+	handlers.SetShutdownTimeout(time.Millisecond * 20)
+	handlers.RegisterTerminationProcedure(hangingProcedureForStackTest(block), "hanging")
+
+	report := handlers.runTerminationProcedures(syscall.SIGTERM)
+	assert.Equal(t, TimedOut, report.Procedures[0].Status)
+	assert.Empty(t, report.Procedures[0].Stack)
+}
+
+func TestCurrentGoroutineIDIsStable(t *testing.T) {
+	id := currentGoroutineID()
+	assert.NotZero(t, id)
+	assert.Equal(t, id, currentGoroutineID())
+	assert.Contains(t, goroutineStack(id), "TestCurrentGoroutineIDIsStable")
+}
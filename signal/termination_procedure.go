@@ -1,11 +1,18 @@
 package signal
 
-import "os"
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
 
 // TerminationFunc is a callback of termination signals.
 // NOTE: if error is not nil, it will be logged out, and the exit code of the whole process will be non zero.
-// Use WrapErrorWithCode to specify the desired exit code or 1 will be returned.
-// The first error returning from TerminationFunc determines the exit code.
+// Use WrapErrorWithCode to specify the desired exit code, otherwise the one set by SetDefaultErrorCode is used.
+// Which failed procedure determines the exit code depends on the ExitCodeStrategy, the first one by default.
 type TerminationFunc func(os.Signal) error
 
 // NewTerminationFunc creates a TerminationFunc without parameter nor return value.
@@ -16,27 +23,171 @@ func NewTerminationFunc(fn func()) TerminationFunc {
 	}
 }
 
+// AsCloser adapts fn into an io.Closer, Close calls fn with nil signal and returns its error.
+func AsCloser(fn TerminationFunc) io.Closer {
+	return closerFunc(fn)
+}
+
+type closerFunc TerminationFunc
+
+func (fn closerFunc) Close() error {
+	return fn(nil)
+}
+
+// TerminationFuncCtx is a TerminationFunc which takes a context, the context is done once the shutdown timeout or
+// the timeout of the procedure fires, see SetShutdownTimeout.
+type TerminationFuncCtx func(context.Context, os.Signal) error
+
+// withContext adapts fn into a TerminationFuncCtx ignoring the context.
+func (fn TerminationFunc) withContext() TerminationFuncCtx {
+	return func(_ context.Context, sig os.Signal) error {
+		return fn(sig)
+	}
+}
+
+// ErrShutdownTimeout is the error of a termination procedure which is still running when shutdown timeout fires.
+var ErrShutdownTimeout = errors.New("shutdown timed out")
+
+// ErrProcedureTimeout is the error of a termination procedure which runs longer than its own timeout.
+var ErrProcedureTimeout = errors.New("termination procedure timed out")
+
+// ProcedureTimeoutExitCode is the exit code selected when the first failed termination procedure runs longer
+// than its own timeout.
+const ProcedureTimeoutExitCode = 124
+
+// ErrAbortTermination is returned, possibly wrapped, by a termination procedure to skip the remaining ones, e.g. when
+// the process is found corrupt. The exit code is selected from the error like other failures, so it can be set by
+// WrapErrorWithCode(ErrAbortTermination, code).
+var ErrAbortTermination = errors.New("termination aborted")
+
+// ErrProcedurePanicked is wrapped by the error of a termination procedure which panics.
+var ErrProcedurePanicked = errors.New("termination procedure panicked")
+
+// NamedProcedure describes a termination procedure, Name is logged before Fn called.
+type NamedProcedure struct {
+	Name  string
+	Stage string
+	Fn    TerminationFunc
+}
+
+// ProcedureID identifies a registered termination procedure, see RemoveTerminationProcedure.
+type ProcedureID uint64
+
+// ProcedureOptions configures a termination procedure, see RegisterTerminationProcedureWithOptions.
+type ProcedureOptions struct {
+	// Message is logged before the procedure called.
+	Message string
+	// Stage groups procedures, see RegisterTerminationProcedureInStage.
+	Stage string
+	// Priority orders procedures, lower runs first, the default is 0.
+	Priority int
+	// Timeout abandons the procedure if it runs longer, zero means no limit.
+	Timeout time.Duration
+	// IgnoreGlobalDeadline makes the procedure run to completion even though the shutdown timeout fires,
+	// it still runs if the shutdown timeout fired before it started.
+	// NOTE: such a procedure can hold the process beyond the shutdown timeout forever unless Timeout is set,
+	// only use it for work which must never be interrupted like committing a transaction.
+	IgnoreGlobalDeadline bool
+}
+
 type terminationProcedure struct {
-	fn      TerminationFunc
-	message string
+	id                   ProcedureID
+	fn                   TerminationFuncCtx
+	message              string
+	stage                string
+	priority             int
+	timeout              time.Duration
+	ignoreGlobalDeadline bool
 }
 
+// ErrInvalidExitCode is returned by WrapErrorWithCodeChecked for a code which is not a valid exit code of failure.
+var ErrInvalidExitCode = errors.New("exit code must be within 1-255")
+
+const (
+	minErrorCode = 1
+	maxErrorCode = 255
+)
+
 type errorWithExitCode struct {
 	error
 	exitCode int
+	// requested is the code given to WrapErrorWithCode before clamping.
+	requested int
+}
+
+// Unwrap returns the wrapped error.
+func (e errorWithExitCode) Unwrap() error {
+	return e.error
+}
+
+// ExitCode returns the exit code wrapped by WrapErrorWithCode anywhere in the chain of err.
+func ExitCode(err error) (int, bool) {
+	var ee errorWithExitCode
+	if errors.As(err, &ee) {
+		return ee.exitCode, true
+	}
+	return 0, false
+}
+
+// panicError turns the value recovered from a termination procedure into an error wrapping ErrProcedurePanicked,
+// the exit code is kept if v is an error returned by WrapErrorWithCode.
+func panicError(v interface{}) error {
+	if err, ok := v.(error); ok {
+		return fmt.Errorf("%w: %w", ErrProcedurePanicked, err)
+	}
+	return fmt.Errorf("%w: %v", ErrProcedurePanicked, v)
 }
 
 func getCodeFromError(e error, def int) int {
-	if ee, ok := e.(errorWithExitCode); ok {
-		return ee.exitCode
+	if code, ok := ExitCode(e); ok {
+		return code
 	}
 	return def
 }
 
 // WrapErrorWithCode wraps given error with exit code, which is useful while returning from a TerminationFunc.
+// NOTE: code is clamped into 1-255 since other values are not meaningful exit codes of failure, e.g. 256 exits with 0.
+// Handlers logs a warning when a clamped code is used, use WrapErrorWithCodeChecked to reject such codes instead.
 func WrapErrorWithCode(e error, code int) error {
 	if e == nil {
 		return nil
 	}
-	return errorWithExitCode{e, code}
+	clamped := code
+	if clamped < minErrorCode {
+		clamped = minErrorCode
+	} else if clamped > maxErrorCode {
+		clamped = maxErrorCode
+	}
+	return errorWithExitCode{e, clamped, code}
+}
+
+// WrapErrorWithCodeChecked is WrapErrorWithCode but returns an error wrapping ErrInvalidExitCode if code is out of 1-255.
+func WrapErrorWithCodeChecked(e error, code int) (error, error) {
+	if code < minErrorCode || code > maxErrorCode {
+		return nil, fmt.Errorf("%w: %d", ErrInvalidExitCode, code)
+	}
+	return WrapErrorWithCode(e, code), nil
+}
+
+// clampedCode returns the code given to WrapErrorWithCode and whether it was clamped.
+func clampedCode(e error) (int, bool) {
+	var ee errorWithExitCode
+	if errors.As(e, &ee) && ee.requested != ee.exitCode {
+		return ee.requested, true
+	}
+	return 0, false
+}
+
+// procedureExitCode returns the exit code selected for the error of a termination procedure, def if it has none.
+// extract is consulted first if not nil, see SetExitCodeExtractor.
+func procedureExitCode(err error, def int, extract func(error) (int, bool)) int {
+	if err == ErrProcedureTimeout {
+		return ProcedureTimeoutExitCode
+	}
+	if extract != nil {
+		if code, ok := extract(err); ok {
+			return code
+		}
+	}
+	return getCodeFromError(err, def)
 }
@@ -1,8 +1,14 @@
 package signal
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"io"
+	"os"
+	"syscall"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -33,3 +39,207 @@ func TestNewTerminationFuncRunsThenReturnsNil(t *testing.T) {
 	assert.Nil(t, fn(nil))
 	assert.Equal(t, 1, called)
 }
+
+func TestWrapErrorWithCodeClampsOutOfRangeCodes(t *testing.T) {
+	for code, expected := range map[int]int{0: 1, -3: 1, 256: 255, 1000: 255, 1: 1, 255: 255} {
+		e := WrapErrorWithCode(io.EOF, code)
+		assert.Equal(t, expected, getCodeFromError(e, -1), code)
+		requested, clamped := clampedCode(e)
+		assert.Equal(t, code != expected, clamped, code)
+		if clamped {
+			assert.Equal(t, code, requested)
+		}
+	}
+}
+
+func TestWrapErrorWithCodeCheckedRejectsOutOfRangeCodes(t *testing.T) {
+	for _, code := range []int{0, -1, 256} {
+		e, err := WrapErrorWithCodeChecked(io.EOF, code)
+		assert.Nil(t, e)
+		assert.True(t, errors.Is(err, ErrInvalidExitCode))
+	}
+
+	e, err := WrapErrorWithCodeChecked(io.EOF, 42)
+	assert.NoError(t, err)
+	assert.Equal(t, 42, getCodeFromError(e, -1))
+
+	e, err = WrapErrorWithCodeChecked(nil, 42)
+	assert.NoError(t, err)
+	assert.Nil(t, e)
+}
+
+func TestAsCloserCallsFuncAndReturnsError(t *testing.T) {
+	called := 0
+	closer := AsCloser(func(sig os.Signal) error {
+		called++
+		assert.Nil(t, sig)
+		return io.EOF
+	})
+	assert.Equal(t, io.EOF, closer.Close())
+	assert.Equal(t, 1, called)
+}
+
+func TestHandlersAbandonsProcedureExceedingItsTimeout(t *testing.T) {
+	t.Parallel()
+	var code int
+	handlers := _newHandlers(func(c int) { code = c })
+	handlers.SetLogger(nopTestLogger{})
+	block := make(chan struct{})
+	defer close(block)
+	handlers.RegisterTerminationProcedureWithTimeout(func(os.Signal) error {
+		<-block
+		return nil
+	}, "slow flush", time.Millisecond*10)
+	handlers.RegisterTerminationProcedure(func(os.Signal) error { return WrapErrorWithCode(io.EOF, 3) }, "close")
+
+	handlers.handleSignal(syscall.SIGTERM)
+	report := handlers.LastShutdownReport()
+	assert.Equal(t, TimedOut, report.Procedures[0].Status)
+	assert.Equal(t, Failed, report.Procedures[1].Status)
+	assert.Equal(t, ProcedureTimeoutExitCode, code)
+}
+
+func TestHandlersContinuesAfterPanicInTheMiddle(t *testing.T) {
+	t.Parallel()
+	var code int
+	handlers := _newHandlers(func(c int) { code = c })
+	handlers.SetLogger(nopTestLogger{})
+	called := ""
+	handlers.RegisterTerminationProcedure(NewTerminationFunc(func() { called += "first " }), "first")
+	handlers.RegisterTerminationProcedure(func(os.Signal) error { panic(io.EOF) }, "middle")
+	handlers.RegisterTerminationProcedure(NewTerminationFunc(func() { called += "last " }), "last")
+
+	handlers.handleSignal(syscall.SIGTERM)
+	assert.Equal(t, "first last ", called)
+	assert.Equal(t, 1, code)
+	report := handlers.LastShutdownReport()
+	assert.Equal(t, []ProcedureStatus{Succeeded, Failed, Succeeded}, []ProcedureStatus{
+		report.Procedures[0].Status, report.Procedures[1].Status, report.Procedures[2].Status,
+	})
+	assert.True(t, report.Procedures[1].Panicked)
+}
+
+func TestHandlersKeepsExitCodeOfPanicWithWrappedError(t *testing.T) {
+	t.Parallel()
+	var code int
+	handlers := _newHandlers(func(c int) { code = c })
+	handlers.SetLogger(nopTestLogger{})
+	handlers.RegisterTerminationProcedure(func(os.Signal) error { panic(WrapErrorWithCode(io.EOF, 7)) }, "")
+
+	handlers.handleSignal(syscall.SIGTERM)
+	assert.Equal(t, 7, code)
+}
+
+func TestHandlersGivesShutdownTimeoutAsContextDeadline(t *testing.T) {
+	t.Parallel()
+	handlers := _newHandlers(nil)
+	handlers.SetLogger(nopTestLogger{})
+	handlers.SetShutdownTimeout(time.Minute)
+	var remaining time.Duration
+	handlers.RegisterTerminationProcedureCtx(func(ctx context.Context, _ os.Signal) error {
+		deadline, ok := ctx.Deadline()
+		assert.True(t, ok)
+		remaining = time.Until(deadline)
+		return nil
+	}, "graceful http shutdown")
+	plainCalled := false
+	handlers.RegisterTerminationProcedure(NewTerminationFunc(func() { plainCalled = true }), "")
+
+	handlers.handleSignal(syscall.SIGTERM)
+	assert.InDelta(t, time.Minute, remaining, float64(time.Second))
+	assert.True(t, plainCalled)
+}
+
+func TestWrapErrorWithCodeUnwraps(t *testing.T) {
+	e := WrapErrorWithCode(io.EOF, 3)
+	assert.True(t, errors.Is(e, io.EOF))
+
+	var pathErr *os.PathError
+	assert.True(t, errors.As(WrapErrorWithCode(&os.PathError{Op: "open", Err: io.EOF}, 3), &pathErr))
+	assert.Equal(t, "open", pathErr.Op)
+}
+
+func TestExitCodeWalksTheChain(t *testing.T) {
+	code, ok := ExitCode(fmt.Errorf("flush: %w", WrapErrorWithCode(io.EOF, 3)))
+	assert.True(t, ok)
+	assert.Equal(t, 3, code)
+	assert.Equal(t, 3, getCodeFromError(fmt.Errorf("flush: %w", WrapErrorWithCode(io.EOF, 3)), 1))
+
+	_, ok = ExitCode(io.EOF)
+	assert.False(t, ok)
+	_, ok = ExitCode(nil)
+	assert.False(t, ok)
+}
+
+func TestHandlersRemoveTerminationProcedureKeepsOrder(t *testing.T) {
+	t.Parallel()
+	handlers := _newHandlers(nil)
+	handlers.SetLogger(nopTestLogger{})
+	called := ""
+	handlers.RegisterTerminationProcedure(NewTerminationFunc(func() { called += "1 " }), "")
+	second := handlers.RegisterTerminationProcedure(NewTerminationFunc(func() { called += "2 " }), "")
+	handlers.RegisterTerminationProcedure(NewTerminationFunc(func() { called += "3" }), "")
+
+	assert.True(t, handlers.RemoveTerminationProcedure(second))
+	assert.False(t, handlers.RemoveTerminationProcedure(second))
+	assert.False(t, handlers.RemoveTerminationProcedure(0))
+	handlers.runTerminationProcedures(syscall.SIGTERM)
+	assert.Equal(t, "1 3", called)
+}
+
+func TestHandlersRemoveTerminationProcedureLeavesRunningShutdownIntact(t *testing.T) {
+	t.Parallel()
+	handlers := _newHandlers(nil)
+	handlers.SetLogger(nopTestLogger{})
+	called := ""
+	var last ProcedureID
+	handlers.RegisterTerminationProcedure(func(os.Signal) error {
+		called += "1 "
+		handlers.RemoveTerminationProcedure(last)
+		return nil
+	}, "")
+	last = handlers.RegisterTerminationProcedure(NewTerminationFunc(func() { called += "2" }), "")
+
+	handlers.runTerminationProcedures(syscall.SIGTERM)
+	assert.Equal(t, "1 2", called)
+	assert.Len(t, handlers.terminationProcedures, 1)
+}
+
+func TestHandlersRunsHigherPriorityProceduresFirst(t *testing.T) {
+	t.Parallel()
+	handlers := _newHandlers(nil)
+	handlers.SetLogger(nopTestLogger{})
+	called := ""
+	handlers.RegisterTerminationProcedure(NewTerminationFunc(func() { called += "close db " }), "")
+	handlers.RegisterTerminationProcedureWithPriority(NewTerminationFunc(func() { called += "flush " }), "", 5)
+	handlers.RegisterTerminationProcedure(NewTerminationFunc(func() { called += "close cache " }), "")
+	handlers.RegisterTerminationProcedureWithPriority(NewTerminationFunc(func() { called += "stop accepting " }), "", -1)
+
+	handlers.runTerminationProcedures(syscall.SIGTERM)
+	assert.Equal(t, "stop accepting close db close cache flush ", called)
+}
+
+func TestHandlersAbortTerminationSkipsRemainingProcedures(t *testing.T) {
+	t.Parallel()
+	logger := &recordingLogger{}
+	ret := -1
+	handlers := _newHandlers(func(code int) { ret = code })
+	handlers.SetLogger(logger)
+	order := ""
+	handlers.RegisterTerminationProcedure(NewTerminationFunc(func() { order += "first " }), "first")
+	handlers.RegisterTerminationProcedure(func(os.Signal) error {
+		order += "check "
+		return WrapErrorWithCode(fmt.Errorf("state corrupt: %w", ErrAbortTermination), 9)
+	}, "check")
+	handlers.RegisterTerminationProcedure(NewTerminationFunc(func() { order += "never " }), "never")
+
+	handlers.handleSignal(syscall.SIGTERM)
+	assert.Equal(t, "first check ", order)
+	assert.Equal(t, 9, ret)
+	assert.True(t, logger.contains("error: termination aborted by procedure: check"))
+	var statuses []ProcedureStatus
+	for _, result := range handlers.LastShutdownReport().Procedures {
+		statuses = append(statuses, result.Status)
+	}
+	assert.Equal(t, []ProcedureStatus{Succeeded, Failed, Skipped}, statuses)
+}
@@ -0,0 +1,47 @@
+package signal
+
+// Tracer creates spans around shutdown, it is a minimal hook to adapt to a tracing library such as OpenTelemetry.
+type Tracer interface {
+	// StartSpan starts a span with given name, the returned function ends it.
+	StartSpan(name string) (Span, func())
+}
+
+// Span is a span started by Tracer.
+type Span interface {
+	// SetStatus sets the status of the span, err is nil unless status is Failed or TimedOut.
+	SetStatus(status ProcedureStatus, err error)
+}
+
+// shutdownSpanName is the name of the span covering all termination procedures.
+const shutdownSpanName = "shutdown"
+
+// SetTracer sets the tracer creating a span for the whole shutdown and, while it is open, one per termination procedure.
+// A nil tracer disables tracing, which is the default.
+func (s *Handlers) SetTracer(tracer Tracer) {
+	s.globalLock.Lock()
+	defer s.globalLock.Unlock()
+	s.tracer = tracer
+}
+
+type nopSpan struct{}
+
+func (nopSpan) SetStatus(ProcedureStatus, error) {}
+
+// startSpan starts a span of tracer, it is a no-op if tracer is nil.
+func startSpan(tracer Tracer, name string) (Span, func()) {
+	if tracer == nil {
+		return nopSpan{}, func() {}
+	}
+	return tracer.StartSpan(name)
+}
+
+// setShutdownStatus sets the status of span from the first failed or timed out procedure of report.
+func setShutdownStatus(span Span, report ShutdownReport) {
+	for _, result := range report.Procedures {
+		if result.Err != nil {
+			span.SetStatus(result.Status, result.Err)
+			return
+		}
+	}
+	span.SetStatus(Succeeded, nil)
+}
@@ -0,0 +1,62 @@
+package signal
+
+import (
+	"io"
+	"os"
+	"sync"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeSpan struct {
+	name   string
+	status ProcedureStatus
+	err    error
+	ended  bool
+}
+
+func (s *fakeSpan) SetStatus(status ProcedureStatus, err error) {
+	s.status, s.err = status, err
+}
+
+type fakeTracer struct {
+	lock  sync.Mutex
+	spans []*fakeSpan
+}
+
+func (t *fakeTracer) StartSpan(name string) (Span, func()) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	span := &fakeSpan{name: name}
+	t.spans = append(t.spans, span)
+	return span, func() { span.ended = true }
+}
+
+func TestHandlersTracesShutdownAndEachProcedure(t *testing.T) {
+	t.Parallel()
+	tracer := &fakeTracer{}
+	handlers := _newHandlers(func(int) {})
+	handlers.SetLogger(nopTestLogger{})
+	handlers.SetTracer(tracer)
+	handlers.RegisterTerminationProcedure(func(os.Signal) error { return nil }, "close listener")
+	handlers.RegisterTerminationProcedure(func(os.Signal) error { return io.EOF }, "flush")
+	handlers.handleSignal(syscall.SIGTERM)
+
+	if !assert.Len(t, tracer.spans, 3) {
+		return
+	}
+	for _, span := range tracer.spans {
+		assert.True(t, span.ended, span.name)
+	}
+	assert.Equal(t, shutdownSpanName, tracer.spans[0].name)
+	assert.Equal(t, Failed, tracer.spans[0].status)
+	assert.Equal(t, io.EOF, tracer.spans[0].err)
+	assert.Equal(t, "close listener", tracer.spans[1].name)
+	assert.Equal(t, Succeeded, tracer.spans[1].status)
+	assert.NoError(t, tracer.spans[1].err)
+	assert.Equal(t, "flush", tracer.spans[2].name)
+	assert.Equal(t, Failed, tracer.spans[2].status)
+	assert.Equal(t, io.EOF, tracer.spans[2].err)
+}
@@ -0,0 +1,51 @@
+package signal
+
+import (
+	"context"
+	"os"
+)
+
+// syntheticSignal is an os.Signal standing for a termination which is not triggered by the OS.
+type syntheticSignal string
+
+func (syntheticSignal) Signal() {}
+
+func (s syntheticSignal) String() string {
+	return string(s)
+}
+
+// Sentinel signals given to termination procedures when termination is not triggered by an OS signal.
+var (
+	// SignalContextCanceled is given when the context passed to TerminateWhenDone is done.
+	SignalContextCanceled os.Signal = syntheticSignal("context canceled")
+	// SignalProgrammatic is given when Terminate is called.
+	SignalProgrammatic os.Signal = syntheticSignal("programmatic termination")
+	// SignalPanic is given when a panic is recovered by RecoverAndShutdown.
+	SignalPanic os.Signal = syntheticSignal("panic")
+)
+
+// Terminate runs termination procedures with SignalProgrammatic then exits.
+// The exit code is code, or the one selected from termination procedures if code is 0.
+// It is dropped while another termination is in progress, e.g. one triggered by a signal.
+// NOTE: it does not depend on StartListen, so termination procedures can be reused where signals are handled elsewhere.
+func (s *Handlers) Terminate(code int) {
+	s.log.Info("termination requested")
+	s.terminate(SignalProgrammatic, code)
+}
+
+// TriggerShutdown terminates like Terminate, it may be called from any handler, e.g. to exit with a chosen code on
+// SIGUSR1. It is dropped while a termination is in progress, so calling it from termination procedures or hooks,
+// or from several handlers, never terminates twice.
+func (s *Handlers) TriggerShutdown(code int) {
+	s.log.Info("shutdown triggered, exit code: ", code)
+	s.terminate(SignalProgrammatic, code)
+}
+
+// TerminateWhenDone terminates with SignalContextCanceled once ctx is done, StartListen is not needed either.
+func (s *Handlers) TerminateWhenDone(ctx context.Context) {
+	go func() {
+		<-ctx.Done()
+		s.log.Info("context done, terminating: ", ctx.Err())
+		s.terminate(SignalContextCanceled, 0)
+	}()
+}
@@ -0,0 +1,135 @@
+package signal
+
+import (
+	"context"
+	"io"
+	"os"
+	"strconv"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTriggerTest(t *testing.T) (*Handlers, <-chan os.Signal, <-chan int) {
+	received := make(chan os.Signal, 1)
+	codes := make(chan int, 1)
+	handlers := _newHandlers(func(code int) { codes <- code })
+	handlers.SetLogger(nopTestLogger{})
+	handlers.RegisterTerminationProcedure(func(sig os.Signal) error {
+		received <- sig
+		return nil
+	}, "")
+	return handlers, received, codes
+}
+
+func TestTerminatePassesSignalProgrammatic(t *testing.T) {
+	t.Parallel()
+	handlers, received, codes := newTriggerTest(t)
+	handlers.Terminate(3)
+	assert.Equal(t, SignalProgrammatic, <-received)
+	assert.Equal(t, 3, <-codes)
+}
+
+func TestTerminateWhenDonePassesSignalContextCanceled(t *testing.T) {
+	t.Parallel()
+	handlers, received, codes := newTriggerTest(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	handlers.TerminateWhenDone(ctx)
+	cancel()
+
+	select {
+	case sig := <-received:
+		assert.Equal(t, SignalContextCanceled, sig)
+	case <-time.After(time.Second):
+		t.Fatal("termination not triggered")
+	}
+	assert.Equal(t, 0, <-codes)
+}
+
+func TestRecoverAndShutdownPassesSignalPanic(t *testing.T) {
+	t.Parallel()
+	handlers, received, codes := newTriggerTest(t)
+	func() {
+		defer handlers.RecoverAndShutdown()
+		panic("boom")
+	}()
+	assert.Equal(t, SignalPanic, <-received)
+	assert.Equal(t, DefaultPanicExitCode, <-codes)
+}
+
+func TestSentinelSignalsAreDistinct(t *testing.T) {
+	assert.NotEqual(t, SignalPanic, SignalProgrammatic)
+	assert.NotEqual(t, SignalPanic, SignalContextCanceled)
+	assert.Equal(t, "panic", SignalPanic.String())
+}
+
+func TestTerminateWorksWithoutListening(t *testing.T) {
+	t.Parallel()
+	handlers := NewHandlers()
+	handlers.SetLogger(nopTestLogger{})
+	codes := make(chan int, 1)
+	handlers.SetExit(func(code int) { codes <- code })
+	var called []string
+	handlers.RegisterTerminationProcedure(func(os.Signal) error {
+		called = append(called, "first")
+		return nil
+	}, "first")
+	handlers.RegisterTerminationProcedure(func(os.Signal) error {
+		called = append(called, "second")
+		return WrapErrorWithCode(io.EOF, 4)
+	}, "second")
+
+	handlers.Terminate(0)
+	assert.Equal(t, []string{"first", "second"}, called)
+	assert.Equal(t, 4, <-codes)
+	assert.Equal(t, SignalProgrammatic, handlers.LastShutdownReport().Signal)
+}
+
+func TestTriggerShutdownFromHandlerExitsWithCode(t *testing.T) {
+	t.Parallel()
+	var steps []string
+	handlers := _newHandlers(func(code int) { steps = append(steps, "exit "+strconv.Itoa(code)) })
+	handlers.SetLogger(nopTestLogger{})
+	handlers.SetOnShutdownComplete(func(code int) { steps = append(steps, "complete "+strconv.Itoa(code)) })
+	handlers.RegisterTerminationProcedure(func(os.Signal) error {
+		steps = append(steps, "procedure")
+		handlers.TriggerShutdown(9)
+		return nil
+	}, "")
+	handlers.RegisterSignalHandler(func(os.Signal) { handlers.TriggerShutdown(7) }, syscall.SIGUSR1)
+
+	handlers.handleSignal(syscall.SIGUSR1)
+	assert.Equal(t, []string{"procedure", "complete 7", "exit 7"}, steps)
+
+	steps = nil
+	handlers.handleSignal(syscall.SIGUSR1)
+	assert.Equal(t, []string{"procedure", "complete 7", "exit 7"}, steps)
+}
+
+func TestTerminateDropsSignalsUntilDone(t *testing.T) {
+	t.Parallel()
+	codes := make(chan int, 2)
+	handlers := _newHandlers(func(code int) { codes <- code })
+	handlers.SetLogger(nopTestLogger{})
+	started := make(chan struct{}, 2)
+	block := make(chan struct{})
+	handlers.RegisterTerminationProcedure(func(os.Signal) error {
+		started <- struct{}{}
+		<-block
+		return nil
+	}, "")
+
+	go handlers.Terminate(3)
+	<-started
+	handlers.Dispatch(syscall.SIGTERM)
+	close(block)
+	assert.Equal(t, 3, <-codes)
+	assert.Len(t, started, 0)
+	assert.Len(t, codes, 0)
+
+	handlers.Dispatch(syscall.SIGTERM)
+	assert.Len(t, started, 1)
+	assert.Equal(t, 0, <-codes)
+}
@@ -0,0 +1,62 @@
+package signal
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"sync"
+	"time"
+)
+
+// latePriority makes a procedure run after all procedures with default priority of its stage.
+const latePriority = math.MaxInt32
+
+// WaitForWaitGroup registers a termination procedure waiting for wg, it runs after other procedures of the "" stage.
+// The procedure fails if wg is not done within timeout, zero timeout means no limit.
+// NOTE: stages run one after another, so procedures of a stage running after the "" stage still run after it,
+// see RegisterTerminationProcedureInStage.
+func (s *Handlers) WaitForWaitGroup(wg *sync.WaitGroup, timeout time.Duration) ProcedureID {
+	return s.WaitForWaitGroupCounted(wg, timeout, nil)
+}
+
+// WaitForWaitGroupCounted is WaitForWaitGroup which reports remaining() in the error when wg is not done in time.
+// remaining is provided by the caller since sync.WaitGroup has no counter accessor, it can be nil.
+func (s *Handlers) WaitForWaitGroupCounted(wg *sync.WaitGroup, timeout time.Duration, remaining func() int) ProcedureID {
+	return s.addTerminationProcedure(terminationProcedure{
+		fn: func(context.Context, os.Signal) error {
+			if waitTimeout(wg, timeout) {
+				return nil
+			}
+			if remaining == nil {
+				return fmt.Errorf("wait group is not done within %s", timeout)
+			}
+			return fmt.Errorf("wait group is not done within %s, %d goroutine(s) remaining", timeout, remaining())
+		},
+		message:  "waiting for wait group",
+		priority: latePriority,
+	})
+}
+
+// waitTimeout waits for wg and reports whether it is done within timeout.
+// NOTE: the goroutine waiting for wg is left behind when timed out.
+func waitTimeout(wg *sync.WaitGroup, timeout time.Duration) bool {
+	if timeout <= 0 {
+		wg.Wait()
+		return true
+	}
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-done:
+		return true
+	case <-timer.C:
+		return false
+	}
+}
@@ -0,0 +1,112 @@
+package signal
+
+import (
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/flexi-cache/pkg/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWaitForWaitGroupWaitsAfterOtherProcedures(t *testing.T) {
+	t.Parallel()
+	handlers := _newHandlers(nil)
+	var wg sync.WaitGroup
+	order := ""
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		time.Sleep(time.Millisecond * 20)
+	}()
+
+	handlers.WaitForWaitGroup(&wg, time.Second)
+	handlers.RegisterTerminationProcedure(NewTerminationFunc(func() { order += "stop " }), "")
+	report := handlers.runTerminationProcedures(syscall.SIGTERM)
+	order += "done"
+	assert.Equal(t, "stop done", order)
+	assert.Equal(t, 0, report.ExitCode)
+	assert.Equal(t, Succeeded, report.Procedures[1].Status)
+}
+
+func TestWaitForWaitGroupRunsLastWithinItsStage(t *testing.T) {
+	t.Parallel()
+	handlers := _newHandlers(nil)
+	var wg sync.WaitGroup
+	order := ""
+
+	handlers.RegisterTerminationProcedure(NewTerminationFunc(func() { order += "stop " }), "")
+	handlers.WaitForWaitGroup(&wg, time.Second)
+	handlers.RegisterTerminationProcedureInStage(NewTerminationFunc(func() { order += "close " }), "", "close")
+	handlers.RegisterTerminationProcedure(NewTerminationFunc(func() { order += "flush " }), "")
+
+	report := handlers.runTerminationProcedures(syscall.SIGTERM)
+	var messages []string
+	for _, result := range report.Procedures {
+		messages = append(messages, result.Message)
+	}
+	assert.Equal(t, []string{"", "", "waiting for wait group", ""}, messages)
+	assert.Equal(t, "close", report.Procedures[3].Stage)
+	assert.Equal(t, "stop flush close ", order)
+}
+
+func TestWaitForWaitGroupRegisteredFirstKeepsLaterStagesAfterIt(t *testing.T) {
+	t.Parallel()
+	handlers := _newHandlers(nil)
+	var wg sync.WaitGroup
+	order := ""
+
+	handlers.WaitForWaitGroup(&wg, time.Second)
+	handlers.RegisterTerminationProcedureInStage(NewTerminationFunc(func() { order += "close " }), "", "close")
+	handlers.RegisterTerminationProcedure(NewTerminationFunc(func() { order += "flush " }), "")
+
+	report := handlers.runTerminationProcedures(syscall.SIGTERM)
+	var messages []string
+	for _, result := range report.Procedures {
+		messages = append(messages, result.Message)
+	}
+	assert.Equal(t, []string{"", "waiting for wait group", ""}, messages)
+	assert.Equal(t, "close", report.Procedures[2].Stage)
+	assert.Equal(t, "flush close ", order)
+}
+
+func TestWaitForWaitGroupFailsWhenNotDoneInTime(t *testing.T) {
+	t.Parallel()
+	handlers := _newHandlers(nil)
+	var wg sync.WaitGroup
+	var running int32 = 2
+	block := make(chan struct{})
+	defer close(block)
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-block
+			atomic.AddInt32(&running, -1)
+		}()
+	}
+
+	handlers.WaitForWaitGroupCounted(&wg, time.Millisecond*20, func() int {
+		return int(atomic.LoadInt32(&running))
+	})
+	report := handlers.runTerminationProcedures(syscall.SIGTERM)
+	assert.Equal(t, 1, report.ExitCode)
+	assert.EqualError(t, report.Procedures[0].Err, "wait group is not done within 20ms, 2 goroutine(s) remaining")
+}
+
+func TestWaitForWaitGroupReturnsRemovableID(t *testing.T) {
+	t.Parallel()
+	handlers := _newHandlers(nil)
+	var wg sync.WaitGroup
+	wg.Add(1)
+	defer wg.Done()
+
+	id := handlers.WaitForWaitGroup(&wg, 0)
+	counted := handlers.WaitForWaitGroupCounted(&wg, 0, nil)
+	assert.NotEqual(t, id, counted)
+	assert.True(t, handlers.RemoveTerminationProcedure(id))
+	assert.True(t, handlers.RemoveTerminationProcedure(counted))
+	testutil.AssertReturnsWithin(t, time.Second, func() { handlers.runTerminationProcedures(syscall.SIGTERM) })
+}
@@ -0,0 +1,88 @@
+package testutil
+
+import (
+	"sync"
+	"time"
+)
+
+// FakeClock is a manually driven clock for time-dependent tests.
+//
+// Time only moves when Advance is called, timers whose deadline is reached are fired by Advance.
+// It is safe for concurrent use.
+type FakeClock struct {
+	lock   sync.Mutex
+	now    time.Time
+	timers []*FakeTimer
+}
+
+// FakeTimer is a timer created by FakeClock.
+type FakeTimer struct {
+	// C receives the clock's current time once the timer fires.
+	C        <-chan time.Time
+	c        chan time.Time
+	clock    *FakeClock
+	deadline time.Time
+}
+
+// NewFakeClock creates a FakeClock starting at given time.
+func NewFakeClock(now time.Time) *FakeClock {
+	return &FakeClock{now: now}
+}
+
+// Now returns the current time of the clock.
+func (c *FakeClock) Now() time.Time {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.now
+}
+
+// After waits for the clock to be advanced by d then sends the current time on the returned channel.
+func (c *FakeClock) After(d time.Duration) <-chan time.Time {
+	return c.NewTimer(d).C
+}
+
+// NewTimer creates a FakeTimer which fires once the clock is advanced by d.
+func (c *FakeClock) NewTimer(d time.Duration) *FakeTimer {
+	ch := make(chan time.Time, 1)
+	timer := &FakeTimer{C: ch, c: ch, clock: c}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	timer.deadline = c.now.Add(d)
+	if d <= 0 {
+		ch <- c.now
+		return timer
+	}
+	c.timers = append(c.timers, timer)
+	return timer
+}
+
+// Advance moves the clock forward by d and fires all timers whose deadline is passed.
+func (c *FakeClock) Advance(d time.Duration) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.now = c.now.Add(d)
+
+	pending := c.timers[:0]
+	for _, timer := range c.timers {
+		if timer.deadline.After(c.now) {
+			pending = append(pending, timer)
+			continue
+		}
+		timer.c <- c.now
+	}
+	c.timers = pending
+}
+
+// Stop prevents the timer from firing, it returns false if the timer has already fired or been stopped.
+func (t *FakeTimer) Stop() bool {
+	t.clock.lock.Lock()
+	defer t.clock.lock.Unlock()
+	for i, timer := range t.clock.timers {
+		if timer == t {
+			t.clock.timers = append(t.clock.timers[:i], t.clock.timers[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,53 @@
+package testutil
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFakeClockTimerFiresOnAdvance(t *testing.T) {
+	start := time.Unix(0, 0)
+	clock := NewFakeClock(start)
+	timer := clock.NewTimer(time.Second)
+
+	clock.Advance(time.Millisecond * 999)
+	select {
+	case <-timer.C:
+		t.Fatal("timer fired before its deadline")
+	default:
+	}
+
+	clock.Advance(time.Millisecond)
+	select {
+	case now := <-timer.C:
+		assert.Equal(t, start.Add(time.Second), now)
+	default:
+		t.Fatal("timer not fired after its deadline")
+	}
+	assert.False(t, timer.Stop())
+}
+
+func TestFakeClockFiresMultiplePendingTimers(t *testing.T) {
+	clock := NewFakeClock(time.Unix(0, 0))
+	first := clock.After(time.Second)
+	second := clock.After(time.Second * 2)
+	third := clock.NewTimer(time.Second * 3)
+
+	clock.Advance(time.Second * 2)
+	assert.Len(t, first, 1)
+	assert.Len(t, second, 1)
+	assert.Len(t, third.C, 0)
+
+	assert.True(t, third.Stop())
+	clock.Advance(time.Second)
+	assert.Len(t, third.C, 0)
+}
+
+func TestFakeClockNowAdvances(t *testing.T) {
+	start := time.Unix(0, 0)
+	clock := NewFakeClock(start)
+	clock.Advance(time.Minute)
+	assert.Equal(t, start.Add(time.Minute), clock.Now())
+}
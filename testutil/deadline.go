@@ -0,0 +1,34 @@
+package testutil
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// AssertReturnsWithin runs f in a goroutine and fails the test if f doesn't return within d.
+//
+// The test is not blocked by an f which never returns, the goroutine running f is left behind.
+func AssertReturnsWithin(t *testing.T, d time.Duration, f func()) bool {
+	t.Helper()
+	return assertReturnsWithin(t, d, f)
+}
+
+func assertReturnsWithin(t assert.TestingT, d time.Duration, f func()) bool {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		f()
+	}()
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-done:
+		return true
+	case <-timer.C:
+		return assert.Fail(t, "function did not return in time",
+			"still running after %s, its goroutine is leaked", d)
+	}
+}
@@ -0,0 +1,33 @@
+package testutil
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type recordingT struct {
+	errors []string
+}
+
+func (r *recordingT) Errorf(format string, args ...interface{}) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func TestAssertReturnsWithinOK(t *testing.T) {
+	called := 0
+	assert.True(t, AssertReturnsWithin(t, time.Second, func() { called++ }))
+	assert.Equal(t, 1, called)
+}
+
+func TestAssertReturnsWithinFailsWhenTimedOut(t *testing.T) {
+	block := make(chan struct{})
+	defer close(block)
+
+	rt := &recordingT{}
+	assert.False(t, assertReturnsWithin(rt, time.Millisecond*10, func() { <-block }))
+	assert.Len(t, rt.errors, 1)
+	assert.Contains(t, rt.errors[0], "goroutine is leaked")
+}
@@ -0,0 +1,24 @@
+package testutil
+
+import (
+	"bytes"
+	"log"
+	"testing"
+)
+
+// CaptureStdLog redirects the standard logger to a buffer while calling f(), then returns what was logged.
+//
+// The output and flags of the standard logger are restored after calling f()
+func CaptureStdLog(t *testing.T, f func()) string {
+	t.Helper()
+	var buf bytes.Buffer
+	output, flags := log.Writer(), log.Flags()
+	log.SetOutput(&buf)
+	defer func() {
+		log.SetOutput(output)
+		log.SetFlags(flags)
+	}()
+
+	f()
+	return buf.String()
+}
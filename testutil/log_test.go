@@ -0,0 +1,19 @@
+package testutil
+
+import (
+	"log"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCaptureStdLogReturnsLoggedContent(t *testing.T) {
+	output, flags := log.Writer(), log.Flags()
+	captured := CaptureStdLog(t, func() {
+		log.SetFlags(0)
+		log.Println("signal received:", "interrupt")
+	})
+	assert.Equal(t, "signal received: interrupt\n", captured)
+	assert.Equal(t, output, log.Writer())
+	assert.Equal(t, flags, log.Flags())
+}
@@ -0,0 +1,33 @@
+//go:build unix
+
+package testutil
+
+import (
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// SendSignal sends sig to the current process and fails the test if it can't be delivered.
+//
+// NOTE: the signal is received by the whole test process, tests sending signals must not run in parallel.
+func SendSignal(t *testing.T, sig syscall.Signal) bool {
+	t.Helper()
+	return sendSignal(t, sig)
+}
+
+// SendSignalAndWait sends sig like SendSignal then fails the test unless condition is met within timeout.
+//
+// condition is polled every millisecond.
+func SendSignalAndWait(t *testing.T, sig syscall.Signal, timeout time.Duration, condition func() bool) bool {
+	t.Helper()
+	return sendSignal(t, sig) &&
+		assert.Eventually(t, condition, timeout, time.Millisecond, "condition not met after signal %s", sig)
+}
+
+func sendSignal(t assert.TestingT, sig syscall.Signal) bool {
+	return assert.NoError(t, syscall.Kill(os.Getpid(), sig), "failed to send signal %s", sig)
+}
@@ -0,0 +1,47 @@
+//go:build unix
+
+package testutil
+
+import (
+	"os"
+	"os/signal"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSendSignalDeliversToCurrentProcess(t *testing.T) {
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, syscall.SIGUSR1)
+	defer signal.Stop(c)
+
+	assert.True(t, SendSignal(t, syscall.SIGUSR1))
+	select {
+	case sig := <-c:
+		assert.Equal(t, syscall.SIGUSR1, sig)
+	case <-time.After(time.Second):
+		t.Fatal("signal not received")
+	}
+}
+
+func TestSendSignalAndWaitPollsCondition(t *testing.T) {
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, syscall.SIGUSR2)
+	defer signal.Stop(c)
+	var received atomic.Bool
+	go func() {
+		<-c
+		received.Store(true)
+	}()
+
+	assert.True(t, SendSignalAndWait(t, syscall.SIGUSR2, time.Second, received.Load))
+}
+
+func TestSendSignalFailsWhenNotDelivered(t *testing.T) {
+	rt := &recordingT{}
+	assert.False(t, sendSignal(rt, syscall.Signal(-1)))
+	assert.Len(t, rt.errors, 1)
+}
@@ -1,8 +1,9 @@
 package testutil
 
 import (
-	"io/ioutil"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -12,18 +13,119 @@ import (
 //
 // The file will be deleted after calling f()
 func WithTempFile(t *testing.T, content string, f func(filename string)) {
-	tmpfile, err := ioutil.TempFile("", t.Name())
-	assert.NoError(t, err)
+	WithTempFileBytes(t, []byte(content), f)
+}
+
+// WithTempFileBytes creates a tempfile with given binary content then calls f()
+//
+// The file will be deleted after calling f()
+func WithTempFileBytes(t *testing.T, content []byte, f func(filename string)) {
+	withTempFile(t, tempPattern(t), content, false, f)
+}
+
+// WithTempFileExt creates a tempfile with given extension and content then calls f(), a missing leading dot is added
+// to ext, e.g. for loaders choosing the format by the extension.
+//
+// The file will be deleted after calling f()
+func WithTempFileExt(t *testing.T, ext, content string, f func(filename string)) {
+	if ext != "" && !strings.HasPrefix(ext, ".") {
+		ext = "." + ext
+	}
+	withTempFile(t, tempPattern(t)+"*"+ext, []byte(content), false, f)
+}
+
+// TempFile creates a tempfile with given content and returns its name.
+//
+// The file will be deleted by t.Cleanup once the test and all its subtests complete, so it may be used by goroutines
+// or subtests outliving the caller.
+func TempFile(t *testing.T, content string) string {
+	t.Helper()
+	filename, cleanup, err := createTempFile(tempPattern(t), []byte(content), false)
+	if !assert.NoError(t, err) {
+		return ""
+	}
+	t.Cleanup(cleanup)
+	return filename
+}
+
+// CreateTempFile creates a tempfile with given content, it suits where no *testing.T is at hand, e.g. benchmarks.
+//
+// The file will be deleted by calling cleanup, which is nil if err is not.
+func CreateTempFile(content string) (filename string, cleanup func(), err error) {
+	return createTempFile("", []byte(content), false)
+}
+
+func withTempFile(t assert.TestingT, pattern string, content []byte, sync bool, f func(filename string)) {
+	filename, cleanup, err := createTempFile(pattern, content, sync)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer cleanup()
 
-	defer func() {
-		if tmpfile != nil {
-			tmpfile.Close()
-		}
+	f(filename)
+}
+
+// tempPattern returns the name of t usable as pattern of temp files, subtests have path separators in their names.
+func tempPattern(t *testing.T) string {
+	return strings.ReplaceAll(t.Name(), "/", "_")
+}
+
+// createTempFile creates a tempfile with given content, nothing is left behind if err is not nil.
+func createTempFile(pattern string, content []byte, sync bool) (filename string, cleanup func(), err error) {
+	tmpfile, err := os.CreateTemp("", pattern)
+	if err != nil {
+		return "", nil, err
+	}
+	cleanup = func() {
+		tmpfile.Close()
 		os.Remove(tmpfile.Name())
-	}()
+	}
+
+	if _, err = tmpfile.Write(content); err == nil && sync {
+		err = tmpfile.Sync()
+	}
+	if err != nil {
+		cleanup()
+		return "", nil, err
+	}
+	return tmpfile.Name(), cleanup, nil
+}
+
+// WithTempFileMode creates a tempfile with given content and mode then calls f()
+//
+// The file will be deleted after calling f()
+func WithTempFileMode(t *testing.T, content string, mode os.FileMode, f func(filename string)) {
+	WithTempFile(t, content, func(filename string) {
+		assert.NoError(t, os.Chmod(filename, mode))
+		f(filename)
+	})
+}
+
+// WithTempFiles creates a temp dir holding a file per name in files with its content then calls f() with the dir and
+// the path of each file by name, names may contain sub directories.
+//
+// The dir will be deleted after calling f()
+func WithTempFiles(t *testing.T, files map[string]string, f func(dir string, paths map[string]string)) {
+	dir, err := os.MkdirTemp("", tempPattern(t))
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
 
-	_, err = tmpfile.Write([]byte(content))
-	assert.NoError(t, err)
+	paths := make(map[string]string, len(files))
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		assert.NoError(t, os.MkdirAll(filepath.Dir(path), 0700))
+		assert.NoError(t, os.WriteFile(path, []byte(content), 0600))
+		paths[name] = path
+	}
 
-	f(tmpfile.Name())
+	f(dir, paths)
+}
+
+// WithSyncedTempFile creates a tempfile with given content, flushes it to disk then calls f()
+//
+// The file will be deleted after calling f()
+func WithSyncedTempFile(t *testing.T, content string, f func(filename string)) {
+	withTempFile(t, tempPattern(t), []byte(content), true, f)
 }
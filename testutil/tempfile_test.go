@@ -0,0 +1,118 @@
+package testutil
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithTempFileBytesKeepsBinaryContent(t *testing.T) {
+	content := []byte{0x1f, 0x8b, 0x00, 0xff, 0x00, '\n'}
+	var name string
+	WithTempFileBytes(t, content, func(filename string) {
+		name = filename
+		read, err := os.ReadFile(filename)
+		assert.NoError(t, err)
+		assert.Equal(t, content, read)
+	})
+	_, err := os.Stat(name)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestWithTempFileModeSetsMode(t *testing.T) {
+	WithTempFileMode(t, "key: value", 0644, func(filename string) {
+		info, err := os.Stat(filename)
+		if assert.NoError(t, err) {
+			assert.Equal(t, os.FileMode(0644), info.Mode().Perm())
+		}
+	})
+}
+
+func TestWithTempFilesCreatesNamedFiles(t *testing.T) {
+	files := map[string]string{"a.yaml": "a: 1", "b.json": "{}", "conf.d/c.toml": "c = 3"}
+	var root string
+	WithTempFiles(t, files, func(dir string, paths map[string]string) {
+		root = dir
+		assert.Len(t, paths, len(files))
+		for name, content := range files {
+			assert.Equal(t, filepath.Join(dir, name), paths[name])
+			read, err := os.ReadFile(paths[name])
+			assert.NoError(t, err)
+			assert.Equal(t, content, string(read))
+		}
+	})
+	_, err := os.Stat(root)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestTempFileRemovedOnCleanup(t *testing.T) {
+	var filename string
+	t.Run("create", func(t *testing.T) {
+		filename = TempFile(t, "content")
+		t.Run("use", func(t *testing.T) {
+			read, err := os.ReadFile(filename)
+			assert.NoError(t, err)
+			assert.Equal(t, "content", string(read))
+		})
+	})
+	_, err := os.Stat(filename)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestWithTempFileFailsWithoutCallingWhenNotCreated(t *testing.T) {
+	rt := &recordingT{}
+	called := false
+	assert.NotPanics(t, func() {
+		withTempFile(rt, "invalid/pattern", []byte("content"), false, func(string) { called = true })
+	})
+	assert.False(t, called)
+	assert.Len(t, rt.errors, 1)
+}
+
+func TestCreateTempFileCleanup(t *testing.T) {
+	filename, cleanup, err := CreateTempFile("content")
+	if !assert.NoError(t, err) {
+		return
+	}
+	read, err := os.ReadFile(filename)
+	assert.NoError(t, err)
+	assert.Equal(t, "content", string(read))
+
+	cleanup()
+	_, err = os.Stat(filename)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestCreateTempFileReturnsError(t *testing.T) {
+	t.Setenv("TMPDIR", filepath.Join(t.TempDir(), "missing"))
+	filename, cleanup, err := CreateTempFile("content")
+	assert.Error(t, err)
+	assert.Empty(t, filename)
+	assert.Nil(t, cleanup)
+}
+
+func TestWithTempFileExtEndsWithExt(t *testing.T) {
+	for _, ext := range []string{".yaml", "json"} {
+		WithTempFileExt(t, ext, "{}", func(filename string) {
+			assert.Equal(t, "."+strings.TrimPrefix(ext, "."), filepath.Ext(filename))
+			read, err := os.ReadFile(filename)
+			assert.NoError(t, err)
+			assert.Equal(t, "{}", string(read))
+		})
+	}
+}
+
+func TestWithSyncedTempFileWritesContent(t *testing.T) {
+	var name string
+	WithSyncedTempFile(t, "key: value", func(filename string) {
+		name = filename
+		read, err := os.ReadFile(filename)
+		assert.NoError(t, err)
+		assert.Equal(t, "key: value", string(read))
+	})
+	_, err := os.Stat(name)
+	assert.True(t, os.IsNotExist(err))
+}